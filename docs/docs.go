@@ -49,7 +49,7 @@ const docTemplate = `{
         },
         "/calculate": {
             "post": {
-                "description": "get the flight path of a person.",
+                "description": "get the flight path of a person. Airport codes are trimmed, upper-cased and mapped from ICAO to IATA before solving. A legacy array body returns [start, end]; a CalculateRequest object body returns an api.CalculateResponse that also lists the rewritten codes. Segments tagged with a source are merged first: records of the same leg (same flight number and date, or same airports) from several sources are solved as one, the most trusted source winning, and the response lists the merges.",
                 "consumes": [
                     "application/json"
                 ],
@@ -63,19 +63,202 @@ const docTemplate = `{
                 "operationId": "flightCalculate-get",
                 "parameters": [
                     {
-                        "description": "Flight segments",
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
                         "name": "flightSegments",
                         "in": "body",
                         "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "[start, end] (legacy array body) or api.CalculateResponse (object body)",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "array",
-                                "items": {
-                                    "type": "string"
-                                }
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/batch": {
+            "post": {
+                "description": "solve each passenger's segments concurrently on a bounded worker pool and return a result or an error per passenger ID, so one bad entry does not fail the batch. Each entry accepts the same shapes as POST /calculate; the anchor, mode and sources query parameters apply to every entry. With a callback URL the batch runs as an asynchronous job instead: the response is 202 with the job, and the finished job (its Batch field holding the results) is POSTed to the callback with an HMAC-SHA256 X-Signature-256 header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Solve many passengers' itineraries in one request.",
+                "operationId": "flightBatch-post",
+                "parameters": [
+                    {
+                        "description": "Passenger ID to flight segments (a CalculateRequest object or the legacy [][]string array)",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/api.CalculateRequest"
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "public http(s) URL to POST the finished batch job to (requires WEBHOOK_SECRET; private hosts need WEBHOOK_ALLOWED_HOSTS); makes the request asynchronous",
+                        "name": "callback",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Queued as a job (callback given)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Job queue is full (callback given)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/components": {
+            "post": {
+                "description": "partition the segments into connected components and solve each one on its own, listing the segment indexes that belong to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Split a multi-trip payload into separate itineraries.",
+                "operationId": "flightComponents-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break round trips (only consulted for circular components)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -84,7 +267,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/api.Component"
                             }
                         }
                     },
@@ -104,6 +287,2051 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/calculate/countries": {
+            "post": {
+                "description": "reconstruct the itinerary, then map every airport to its ISO 3166-1 alpha-2 country and report the countries in travel order, the international border crossings, and whether the trip is domestic-only. Every airport must be in the embedded airport dataset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "List the countries and border crossings of an itinerary.",
+                "operationId": "flightCountries-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.CountryReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/emissions": {
+            "post": {
+                "description": "reconstruct the itinerary, then estimate per-passenger CO2 per leg and in total with the distance-band method (short/medium/long haul factors, 8% distance uplift) and a cabin-class multiplier. Every airport must be in the embedded airport dataset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Estimate the CO2 emissions of an itinerary.",
+                "operationId": "flightEmissions-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "economy",
+                            "premium_economy",
+                            "business",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Cabin class (default economy)",
+                        "name": "cabin",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Emissions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/gaps": {
+            "post": {
+                "description": "list the partial paths found in the segments, every end-to-start connection between them, and the smallest set of bridging segments that joins them into a single path.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Suggest the missing segments that would connect a broken itinerary.",
+                "operationId": "flightGaps-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.GapAnalysis"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/itinerary": {
+            "post": {
+                "description": "get every airport and segment of the flight path in travel order, listing the airport codes that were normalized (trimmed, upper-cased, ICAO mapped to IATA) and the source-tagged records that were merged or discarded before solving.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Reconstruct the full ordered itinerary of a person.",
+                "operationId": "flightItinerary-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Itinerary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/stream": {
+            "post": {
+                "description": "read one itinerary per line of an application/x-ndjson body and write one result line back per input line, in order, as each is solved. The body is never buffered whole and is exempt from the 1 MiB request limit, which applies per line instead. Each line accepts the same shapes as POST /calculate; blank lines are skipped but still counted. The anchor, mode and sources query parameters apply to every line.",
+                "consumes": [
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Stream itineraries as newline-delimited JSON.",
+                "operationId": "flightStream-post",
+                "parameters": [
+                    {
+                        "description": "One CalculateRequest object (or legacy [][]string array) per line",
+                        "name": "stream",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "One StreamResult per input line",
+                        "schema": {
+                            "$ref": "#/definitions/api.StreamResult"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/calculate/summary": {
+            "post": {
+                "description": "reconstruct the itinerary, then report per-leg and total great-circle distance (km, mi, nm), the direct origin-to-destination distance, the detour ratio, estimated and scheduled flight times, and the CO2 estimate (see /calculate/emissions). Every airport must be in the embedded airport dataset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "FlightCalculate"
+                ],
+                "summary": "Summarize the distances and flight times of an itinerary.",
+                "operationId": "flightSummary-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "economy",
+                            "premium_economy",
+                            "business",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Cabin class for the emissions estimate (default economy)",
+                        "name": "cabin",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/itineraries": {
+            "get": {
+                "description": "return one page of saved itineraries in the order they were saved, with the total count. limit defaults to 20 and is capped at 100.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Itineraries"
+                ],
+                "summary": "List saved itineraries.",
+                "operationId": "itineraryList-get",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of itineraries to skip (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ItineraryPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "solve the flight segments as POST /calculate does and, when they form a valid itinerary, save the normalized segments, the options used and the computed path. Invalid input is rejected with the same 400 responses and nothing is saved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Itineraries"
+                ],
+                "summary": "Solve and save an itinerary.",
+                "operationId": "itinerarySave-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.StoredItinerary"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted version number"
+                            },
+                            "Location": {
+                                "type": "string",
+                                "description": "/itineraries/{id}"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/itineraries/{id}": {
+            "get": {
+                "description": "return the current version of the itinerary saved under the given ID. The ETag header carries its version for If-Match on PATCH.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Itineraries"
+                ],
+                "summary": "Get a saved itinerary.",
+                "operationId": "itineraryGet-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StoredItinerary"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted version number"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Itinerary not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "apply a JSON Patch (RFC 6902, application/json-patch+json) or a JSON merge patch (RFC 7396, application/merge-patch+json) to the current version of the itinerary, as returned by GET, to replace, add or remove Segments or change the mode and anchor Options. The result is validated and solved as POST /itineraries does and saved as the next version; invalid edits are rejected with the same 400 responses and nothing is saved. If-Match must carry the ETag of the version being edited (or *); when another edit got there first the request fails with 412 and the current ETag.",
+                "consumes": [
+                    "application/json-patch+json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Itineraries"
+                ],
+                "summary": "Edit a saved itinerary.",
+                "operationId": "itineraryPatch-patch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being edited",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Patch array or JSON merge patch object",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StoredItinerary"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted version number"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Itinerary not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "412": {
+                        "description": "Itinerary has changed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/itineraries/{id}/versions": {
+            "get": {
+                "description": "return every version of the itinerary, from the one first saved to the current one. Each PATCH adds a version; earlier ones are never changed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Itineraries"
+                ],
+                "summary": "List a saved itinerary's versions.",
+                "operationId": "itineraryVersions-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ItineraryVersions"
+                        }
+                    },
+                    "404": {
+                        "description": "Itinerary not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "post": {
+                "description": "validate the flight segments, queue them for the solver and return the new job at once with its ID; poll GET /jobs/{id} for the result, or pass a callback URL to have the finished job POSTed there with an HMAC-SHA256 X-Signature-256 header and exponential-backoff retries. The body accepts the same shapes as POST /calculate, and the anchor and mode query parameters apply as they do there. Finished jobs are kept until their Expires time (JOB_TTL after finishing).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "Submit a calculation to run asynchronously.",
+                "operationId": "jobSubmit-post",
+                "parameters": [
+                    {
+                        "description": "Flight segments: a CalculateRequest object, or the legacy [][]string array",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Home airport used to break a round trip (only consulted for circular input)",
+                        "name": "anchor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "path",
+                            "eulerian"
+                        ],
+                        "type": "string",
+                        "description": "Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "public http(s) URL the finished job is POSTed to (requires WEBHOOK_SECRET; private hosts need WEBHOOK_ALLOWED_HOSTS)",
+                        "name": "callback",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.Job"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/jobs/{id}"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Job queue is full",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "description": "return the job's status (queued, running, succeeded, failed or canceled), once it has finished its result, and for a job with a callback every delivery attempt. Unknown and expired job IDs return 404.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "Get the status and result of an asynchronous job.",
+                "operationId": "jobGet-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "cancel a queued or running job and return it with status canceled. A queued job never runs; a running job stops before its next solve (a batch stops between entries), while a solve already under way runs to completion and its result is discarded. A job that has already finished cannot be canceled and returns 409 with its current state.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Jobs"
+                ],
+                "summary": "Cancel an asynchronous job.",
+                "operationId": "jobCancel-delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Job already finished",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/passengers": {
+            "post": {
+                "description": "create a passenger with an optional name and no segments; append timestamped segments with POST /passengers/{id}/segments.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passengers"
+                ],
+                "summary": "Register a passenger.",
+                "operationId": "passengerCreate-post",
+                "parameters": [
+                    {
+                        "description": "Passenger details",
+                        "name": "passenger",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.PassengerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Passenger"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/passengers/{id}"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/passengers/{id}": {
+            "get": {
+                "description": "return the passenger and every segment appended so far, in the order they were appended.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passengers"
+                ],
+                "summary": "Get a passenger.",
+                "operationId": "passengerGet-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passenger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Passenger"
+                        }
+                    },
+                    "404": {
+                        "description": "Passenger not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/passengers/{id}/contacts": {
+            "get": {
+                "description": "list every other passenger who flew the same flight number on the same date as this one, or was at the same airport within the window of them, with the shared legs and airport stays. Answered from an index keyed by (flight number, date) and (airport, hour).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passengers"
+                ],
+                "summary": "Trace a passenger's contacts.",
+                "operationId": "passengerContacts-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passenger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Largest gap between two airport stays that still links them, as a Go duration up to 24h (default 2h)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ContactTrace"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Passenger not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/passengers/{id}/location": {
+            "get": {
+                "description": "reconstruct the passenger's itinerary from the timestamped segments and report whether, at the given instant, they were in flight (with the segment), at an airport between two legs, not yet departed from the origin, or arrived at the final destination.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passengers"
+                ],
+                "summary": "Find where a passenger was at a given time.",
+                "operationId": "passengerLocate-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passenger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 instant, e.g. 2026-03-01T09:00:00Z",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Passenger not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/passengers/{id}/segments": {
+            "post": {
+                "description": "add segments to the passenger's record. The body accepts the same shapes as POST /calculate, and every segment needs both a departure and an arrival time. The passenger's segments, old and new together, must still form one valid timed itinerary; otherwise nothing is appended and the chronology error is returned, with Indexes counting the existing segments first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passengers"
+                ],
+                "summary": "Append flight segments to a passenger.",
+                "operationId": "passengerAppend-post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passenger ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Timestamped flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination, departure, arrival]",
+                        "name": "flightSegments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CalculateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Passenger"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Passenger not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "post": {
+                "description": "create an empty session; append segments one at a time with POST /sessions/{id}/segments and read the running start, end and status with GET /sessions/{id}. A session is dropped once it has not been used for SESSION_TTL (its Expires time, moved forward by every request for it), or with DELETE /sessions/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Open an incremental itinerary session.",
+                "operationId": "sessionCreate-post",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.Session"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/sessions/{id}"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Too many open sessions",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}": {
+            "get": {
+                "description": "return the start, end and status of the segments appended so far. The state is kept up to date on every append, so reading it does not re-solve the itinerary.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Get a session's current itinerary state.",
+                "operationId": "sessionGet-get",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Session"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "drop the session and its segments at once instead of waiting for it to expire.",
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Close a session.",
+                "operationId": "sessionDelete-delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}/segments": {
+            "post": {
+                "description": "add a single segment, validated and normalized like a segment of POST /calculate, and return the updated state. A segment that gives an airport a second distinct outgoing or incoming flight, or that would take the session past SESSION_MAX_SEGMENTS, is rejected with 409 and the session is unchanged; Indexes are session segment positions, the rejected one last.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Append one segment to a session.",
+                "operationId": "sessionAppend-post",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "One flight segment",
+                        "name": "segment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.Segment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Session"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Segment conflicts with the session",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.BatchResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.BatchResult": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "end": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.BorderCrossing": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CalculateRequest": {
+            "type": "object",
+            "properties": {
+                "options": {
+                    "$ref": "#/definitions/api.Options"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Segment"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Callback": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DeliveryAttempt"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Component": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "indexes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Contact": {
+            "type": "object",
+            "properties": {
+                "flights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Flight"
+                    }
+                },
+                "passenger": {
+                    "type": "string"
+                },
+                "stays": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Stay"
+                    }
+                }
+            }
+        },
+        "api.ContactTrace": {
+            "type": "object",
+            "properties": {
+                "contacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Contact"
+                    }
+                },
+                "passenger": {
+                    "type": "string"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CountryReport": {
+            "type": "object",
+            "properties": {
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "crossings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BorderCrossing"
+                    }
+                },
+                "domestic": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.DeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "attempt": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "statusCode": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Distance": {
+            "type": "object",
+            "properties": {
+                "km": {
+                    "type": "number"
+                },
+                "mi": {
+                    "type": "number"
+                },
+                "nm": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.Emissions": {
+            "type": "object",
+            "properties": {
+                "cabin": {
+                    "type": "string"
+                },
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.LegEmissions"
+                    }
+                },
+                "totalKgCO2": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.Flight": {
+            "type": "object",
+            "properties": {
+                "arrival": {
+                    "type": "string"
+                },
+                "departure": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.GapAnalysis": {
+            "type": "object",
+            "properties": {
+                "bridges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Flight"
+                    }
+                },
+                "ends": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fragments": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "starts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.Itinerary": {
+            "type": "object",
+            "properties": {
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Flight"
+                    }
+                },
+                "merges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Merge"
+                    }
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rewrites": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Rewrite"
+                    }
+                }
+            }
+        },
+        "api.ItineraryPage": {
+            "type": "object",
+            "properties": {
+                "itineraries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StoredItinerary"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ItineraryVersions": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StoredItinerary"
+                    }
+                }
+            }
+        },
+        "api.Job": {
+            "type": "object",
+            "properties": {
+                "batch": {
+                    "$ref": "#/definitions/api.BatchResponse"
+                },
+                "callback": {
+                    "$ref": "#/definitions/api.Callback"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "finished": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/api.BatchResult"
+                },
+                "started": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "submitted": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.LegEmissions": {
+            "type": "object",
+            "properties": {
+                "band": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "kgCO2": {
+                    "type": "number"
+                },
+                "km": {
+                    "type": "number"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.LegSummary": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "$ref": "#/definitions/api.Distance"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "estimatedMinutes": {
+                    "type": "integer"
+                },
+                "scheduledMinutes": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Location": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "passenger": {
+                    "type": "string"
+                },
+                "segment": {
+                    "$ref": "#/definitions/api.Flight"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Merge": {
+            "type": "object",
+            "properties": {
+                "discarded": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "kept": {
+                    "type": "integer"
+                },
+                "merged": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "segment": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Options": {
+            "type": "object",
+            "properties": {
+                "anchor": {
+                    "type": "string"
+                },
+                "cabin": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.Passenger": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Flight"
+                    }
+                }
+            }
+        },
+        "api.PassengerRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Rewrite": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Segment": {
+            "type": "object",
+            "properties": {
+                "arrives": {
+                    "type": "string"
+                },
+                "departs": {
+                    "type": "string"
+                },
+                "flight": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Session": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "integer"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "endCandidates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "segments": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "startCandidates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Stay": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.StoredItinerary": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modified": {
+                    "type": "string"
+                },
+                "options": {
+                    "$ref": "#/definitions/api.Options"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Flight"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.StreamResult": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "end": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Summary": {
+            "type": "object",
+            "properties": {
+                "detourRatio": {
+                    "type": "number"
+                },
+                "direct": {
+                    "$ref": "#/definitions/api.Distance"
+                },
+                "emissions": {
+                    "$ref": "#/definitions/api.Emissions"
+                },
+                "estimatedMinutes": {
+                    "type": "integer"
+                },
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.LegSummary"
+                    }
+                },
+                "scheduledMinutes": {
+                    "type": "integer"
+                },
+                "total": {
+                    "$ref": "#/definitions/api.Distance"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,189 @@
+// Package airports is a read-only registry of airports loaded from an
+// embedded reference dataset (airports.csv). Each record carries the IATA and
+// ICAO codes, name, city, ISO 3166-1 alpha-2 country, coordinates, and IANA
+// time zone. The dataset covers major commercial airports plus every code used
+// by the project's fixtures, including the Chicago metropolitan code CHI,
+// which has no ICAO code and is placed at the city centre; extend it by adding
+// rows to airports.csv.
+//
+// CSV columns (header row required, in this order):
+//
+//	iata,icao,name,city,country,lat,lon,tz
+package airports
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	// Embedded zone data, so local times work on images without tzdata.
+	_ "time/tzdata"
+)
+
+//go:embed airports.csv
+var dataset string
+
+// EarthRadiusKm is the mean Earth radius used for great-circle distances.
+const EarthRadiusKm = 6371.0088
+
+// header is the expected first row of the dataset.
+var header = []string{"iata", "icao", "name", "city", "country", "lat", "lon", "tz"}
+
+// ErrMalformed is returned by Load when the dataset does not match the
+// expected columns or contains an invalid row.
+var ErrMalformed = errors.New("malformed airport dataset")
+
+// Airport is one record of the reference dataset.
+type Airport struct {
+	IATA     string
+	ICAO     string
+	Name     string
+	City     string
+	Country  string
+	Lat      float64
+	Lon      float64
+	Timezone string
+}
+
+// Registry indexes airports by IATA and ICAO code. The zero value is an empty
+// registry; a Registry is safe for concurrent reads.
+type Registry struct {
+	byIATA map[string]Airport
+	byICAO map[string]Airport
+	zones  map[string]*time.Location // by IANA name
+}
+
+// Load parses a dataset in the airports.csv format. Duplicate codes,
+// unknown time zones and malformed rows are rejected with an error wrapping
+// ErrMalformed.
+func Load(r io.Reader) (*Registry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(header)
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(header, ",") {
+		return nil, fmt.Errorf("%w: header must be %q", ErrMalformed, strings.Join(header, ","))
+	}
+
+	reg := &Registry{
+		byIATA: make(map[string]Airport, len(rows)-1),
+		byICAO: make(map[string]Airport, len(rows)-1),
+		zones:  make(map[string]*time.Location),
+	}
+	for n, row := range rows[1:] {
+		a, err := parseRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %w", ErrMalformed, n+2, err)
+		}
+		if _, dup := reg.byIATA[a.IATA]; dup {
+			return nil, fmt.Errorf("%w: row %d: duplicate IATA code %s", ErrMalformed, n+2, a.IATA)
+		}
+		if _, loaded := reg.zones[a.Timezone]; a.Timezone != "" && !loaded {
+			loc, err := time.LoadLocation(a.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("%w: row %d: unknown time zone %q", ErrMalformed, n+2, a.Timezone)
+			}
+			reg.zones[a.Timezone] = loc
+		}
+		reg.byIATA[a.IATA] = a
+		if a.ICAO != "" {
+			if _, dup := reg.byICAO[a.ICAO]; dup {
+				return nil, fmt.Errorf("%w: row %d: duplicate ICAO code %s", ErrMalformed, n+2, a.ICAO)
+			}
+			reg.byICAO[a.ICAO] = a
+		}
+	}
+	return reg, nil
+}
+
+// parseRow converts one CSV row into an Airport.
+func parseRow(row []string) (Airport, error) {
+	a := Airport{
+		IATA:     row[0],
+		ICAO:     row[1],
+		Name:     row[2],
+		City:     row[3],
+		Country:  row[4],
+		Timezone: row[7],
+	}
+	if len(a.IATA) != 3 || (a.ICAO != "" && len(a.ICAO) != 4) {
+		return Airport{}, fmt.Errorf("invalid codes %q/%q", a.IATA, a.ICAO)
+	}
+	var err error
+	if a.Lat, err = strconv.ParseFloat(row[5], 64); err != nil || a.Lat < -90 || a.Lat > 90 {
+		return Airport{}, fmt.Errorf("invalid latitude %q", row[5])
+	}
+	if a.Lon, err = strconv.ParseFloat(row[6], 64); err != nil || a.Lon < -180 || a.Lon > 180 {
+		return Airport{}, fmt.Errorf("invalid longitude %q", row[6])
+	}
+	return a, nil
+}
+
+// Default returns the registry built from the embedded dataset. It is parsed
+// once, on first use; a malformed embedded dataset is a build defect and
+// panics.
+var Default = sync.OnceValue(func() *Registry {
+	reg, err := Load(strings.NewReader(dataset))
+	if err != nil {
+		panic(err)
+	}
+	return reg
+})
+
+// Lookup returns the airport with the given IATA code.
+func (r *Registry) Lookup(iata string) (Airport, bool) {
+	a, ok := r.byIATA[iata]
+	return a, ok
+}
+
+// LookupICAO returns the airport with the given ICAO code.
+func (r *Registry) LookupICAO(icao string) (Airport, bool) {
+	a, ok := r.byICAO[icao]
+	return a, ok
+}
+
+// Canonical returns code trimmed of surrounding whitespace and upper-cased,
+// with a known ICAO code replaced by the airport's IATA code. Unknown codes
+// are returned trimmed and upper-cased, so the result is stable for any input.
+func (r *Registry) Canonical(code string) string {
+	c := strings.ToUpper(strings.TrimSpace(code))
+	if a, ok := r.byICAO[c]; ok {
+		return a.IATA
+	}
+	return c
+}
+
+// LocalDate returns the calendar date (YYYY-MM-DD) of t in the time zone of
+// the airport with the given IATA code, so the same instant gives the same
+// date whatever offset it was written in. For an unknown airport, or one
+// without a time zone, it falls back to the UTC date.
+func (r *Registry) LocalDate(iata string, t time.Time) string {
+	loc, ok := r.zones[r.byIATA[iata].Timezone]
+	if !ok {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.DateOnly)
+}
+
+// Len returns the number of airports in the registry.
+func (r *Registry) Len() int {
+	return len(r.byIATA)
+}
+
+// Distance returns the great-circle distance between a and b in kilometres,
+// using the haversine formula on a spherical Earth of radius EarthRadiusKm.
+func Distance(a, b Airport) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,181 @@
+package airports
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestDefault(t *testing.T) {
+	reg := Default()
+	if reg.Len() < 100 {
+		t.Errorf("Len() = %d, want at least 100 airports", reg.Len())
+	}
+	sfo, ok := reg.Lookup("SFO")
+	if !ok {
+		t.Fatal("SFO not found")
+	}
+	if sfo.ICAO != "KSFO" || sfo.Country != "US" || sfo.Timezone != "America/Los_Angeles" {
+		t.Errorf("SFO = %+v", sfo)
+	}
+	if a, ok := reg.LookupICAO("EGLL"); !ok || a.IATA != "LHR" {
+		t.Errorf("LookupICAO(EGLL) = %+v, %v, want LHR", a, ok)
+	}
+	for _, code := range []string{"XXX", "sfo", "SFO "} {
+		if _, ok := reg.Lookup(code); ok {
+			t.Errorf("Lookup(%q) found an airport, want none", code)
+		}
+	}
+}
+
+func TestDefaultCoversFixtures(t *testing.T) {
+	reg := Default()
+	for _, f := range api.TestFlights {
+		for _, code := range []string{f.Start, f.End} {
+			if _, ok := reg.Lookup(code); !ok {
+				t.Errorf("fixture airport %s missing from the dataset", code)
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	reg := Default()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"SFO", "SFO"},
+		{"sfo", "SFO"},
+		{" SFO\t", "SFO"},
+		{"KSFO", "SFO"},
+		{"ksfo ", "SFO"},
+		{"xxx", "XXX"},
+		{"ZZZZ", "ZZZZ"},
+	}
+	for _, tt := range tests {
+		if got := reg.Canonical(tt.in); got != tt.want {
+			t.Errorf("Canonical(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	reg := Default()
+	tests := []struct {
+		from, to string
+		wantKm   float64
+	}{
+		{"SFO", "SFO", 0},
+		{"JFK", "LHR", 5540},
+		{"SFO", "ATL", 3442},
+		{"SYD", "LAX", 12051},
+	}
+	for _, tt := range tests {
+		a, _ := reg.Lookup(tt.from)
+		b, _ := reg.Lookup(tt.to)
+		got := Distance(a, b)
+		if math.Abs(got-tt.wantKm) > tt.wantKm*0.005+0.001 {
+			t.Errorf("Distance(%s, %s) = %.1f km, want ~%.0f km", tt.from, tt.to, got, tt.wantKm)
+		}
+		if back := Distance(b, a); math.Abs(back-got) > 1e-9 {
+			t.Errorf("Distance is not symmetric for %s/%s: %f vs %f", tt.from, tt.to, got, back)
+		}
+	}
+}
+
+func TestLocalDate(t *testing.T) {
+	reg := Default()
+	// 01:30 UTC on 2 March is still the evening of 1 March in New York.
+	late := time.Date(2026, 3, 2, 1, 30, 0, 0, time.UTC)
+	tests := []struct {
+		iata string
+		at   time.Time
+		want string
+	}{
+		{"JFK", late, "2026-03-01"},
+		{"JFK", late.In(time.FixedZone("", -5*3600)), "2026-03-01"},
+		{"LHR", late, "2026-03-02"},
+		{"NRT", time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC), "2026-03-02"},
+		{"XXX", late.In(time.FixedZone("", -5*3600)), "2026-03-02"},
+	}
+	for _, tt := range tests {
+		if got := reg.LocalDate(tt.iata, tt.at); got != tt.want {
+			t.Errorf("LocalDate(%s, %v) = %s, want %s", tt.iata, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	const head = "iata,icao,name,city,country,lat,lon,tz\n"
+	tests := []struct {
+		name    string
+		data    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "valid rows",
+			data:    head + "SFO,KSFO,San Francisco,San Francisco,US,37.6,-122.4,America/Los_Angeles\nCHI,,Chicago,Chicago,US,41.9,-87.6,America/Chicago\n",
+			wantLen: 2,
+		},
+		{
+			name:    "header only",
+			data:    head,
+			wantLen: 0,
+		},
+		{
+			name:    "missing header",
+			data:    "SFO,KSFO,San Francisco,San Francisco,US,37.6,-122.4,America/Los_Angeles\n",
+			wantErr: true,
+		},
+		{
+			name:    "wrong column count",
+			data:    head + "SFO,KSFO,San Francisco\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid latitude",
+			data:    head + "SFO,KSFO,San Francisco,San Francisco,US,97.6,-122.4,America/Los_Angeles\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid IATA code",
+			data:    head + "SFOX,KSFO,San Francisco,San Francisco,US,37.6,-122.4,America/Los_Angeles\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown time zone",
+			data:    head + "SFO,KSFO,San Francisco,San Francisco,US,37.6,-122.4,America/San_Francisco\n",
+			wantErr: true,
+		},
+		{
+			name: "duplicate IATA code",
+			data: head + "SFO,KSFO,San Francisco,San Francisco,US,37.6,-122.4,America/Los_Angeles\n" +
+				"SFO,KSQL,San Carlos,San Carlos,US,37.5,-122.2,America/Los_Angeles\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg, err := Load(strings.NewReader(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrMalformed) {
+					t.Fatalf("error = %v, want ErrMalformed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reg.Len() != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", reg.Len(), tt.wantLen)
+			}
+		})
+	}
+}
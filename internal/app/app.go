@@ -4,6 +4,7 @@ package app
 
 import (
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -15,14 +16,37 @@ import (
 	// Swagger spec with swag's global registry — without this, GET
 	// /swagger/doc.json returns 500.
 	_ "github.com/AndriyKalashnykov/flight-path/docs"
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/internal/contacts"
 	"github.com/AndriyKalashnykov/flight-path/internal/handlers"
+	"github.com/AndriyKalashnykov/flight-path/internal/jobs"
 	"github.com/AndriyKalashnykov/flight-path/internal/routes"
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/internal/webhook"
 )
 
 // New builds a fully-configured Echo instance with middleware and routes.
+// It fails only when the itinerary store cannot be opened or read.
 // Reads CORS_ORIGIN from the environment (defaults to "*"); a comma-separated
-// list is supported for multi-origin allowlists.
-func New() *echo.Echo {
+// list is supported for multi-origin allowlists. AIRPORT_VALIDATION=strict
+// rejects airport codes missing from the embedded registry (default lenient);
+// BATCH_WORKERS bounds batch concurrency (default GOMAXPROCS); JOB_WORKERS,
+// JOB_QUEUE_SIZE and JOB_TTL configure the asynchronous job queue, and
+// WEBHOOK_SECRET enables job callbacks (see webhookSender). STORE_PATH
+// saves itineraries to a bbolt file instead of memory. SOURCE_PRIORITY ranks
+// the sources of source-tagged segments, most trusted first. SESSION_TTL,
+// SESSION_MAX_OPEN and SESSION_MAX_SEGMENTS bound incremental sessions.
+func New() (*echo.Echo, error) {
+	itineraries, err := openStore(os.Getenv("STORE_PATH"))
+	if err != nil {
+		return nil, err
+	}
+	index, err := contacts.Load(itineraries, airports.Default())
+	if err != nil {
+		_ = itineraries.Close()
+		return nil, err
+	}
+
 	e := echo.New()
 
 	e.HTTPErrorHandler = echo.DefaultHTTPErrorHandler(false)
@@ -30,7 +54,14 @@ func New() *echo.Echo {
 	e.Use(middleware.RequestID())
 	e.Use(middleware.RequestLogger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.BodyLimit(1 << 20)) // 1 MiB
+	// 1 MiB per request, except the NDJSON stream, which is read line by
+	// line and caps each line instead.
+	e.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{
+		LimitBytes: 1 << 20,
+		Skipper: func(c *echo.Context) bool {
+			return c.Request().URL.Path == "/calculate/stream"
+		},
+	}))
 	e.Use(middleware.Gzip())
 	// Per-IP rate limiter using the in-memory store. 100 req/s sustained,
 	// 200-request burst. Tunable via env (RATE_LIMIT_PER_SEC,
@@ -61,12 +92,42 @@ func New() *echo.Echo {
 		}
 	})
 
-	h := handlers.New()
+	h := handlers.New(
+		handlers.WithStrictAirports(strings.EqualFold(os.Getenv("AIRPORT_VALIDATION"), "strict")),
+		handlers.WithBatchWorkers(envInt("BATCH_WORKERS", runtime.GOMAXPROCS(0))),
+		handlers.WithJobs(jobs.New(
+			envInt("JOB_WORKERS", runtime.GOMAXPROCS(0)),
+			envInt("JOB_QUEUE_SIZE", jobs.DefaultCapacity),
+			envDuration("JOB_TTL", jobs.DefaultTTL),
+			webhookSender(),
+		)),
+		handlers.WithStore(itineraries),
+		handlers.WithContacts(index),
+		handlers.WithSourcePriority(strings.Split(os.Getenv("SOURCE_PRIORITY"), ",")),
+		handlers.WithSessions(
+			envDuration("SESSION_TTL", handlers.DefaultSessionTTL),
+			envInt("SESSION_MAX_OPEN", handlers.DefaultMaxSessions),
+			envInt("SESSION_MAX_SEGMENTS", handlers.DefaultMaxSessionSegments),
+		),
+	)
 	routes.SwaggerRoutes(e)
 	routes.HealthcheckRoutes(e, &h)
 	routes.FlightRoutes(e, &h)
+	routes.JobRoutes(e, &h)
+	routes.ItineraryRoutes(e, &h)
+	routes.PassengerRoutes(e, &h)
+	routes.SessionRoutes(e, &h)
 
-	return e
+	return e, nil
+}
+
+// openStore opens the bbolt store at path, or an in-memory store when path
+// is empty.
+func openStore(path string) (store.Store, error) {
+	if path == "" {
+		return store.NewMemory(), nil
+	}
+	return store.OpenBolt(path)
 }
 
 func envFloat(key string, fallback float64) float64 {
@@ -78,6 +139,32 @@ func envFloat(key string, fallback float64) float64 {
 	return fallback
 }
 
+// webhookSender returns the sender for job callbacks, signing with
+// WEBHOOK_SECRET and retrying per WEBHOOK_MAX_ATTEMPTS and WEBHOOK_BACKOFF.
+// WEBHOOK_ALLOWED_HOSTS lists the private or loopback hosts it may reach.
+// Without a secret callbacks are disabled and nil is returned.
+func webhookSender() *webhook.Sender {
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		return nil
+	}
+	return webhook.New(
+		secret,
+		envInt("WEBHOOK_MAX_ATTEMPTS", webhook.DefaultMaxAttempts),
+		envDuration("WEBHOOK_BACKOFF", webhook.DefaultBackoff),
+		strings.Split(os.Getenv("WEBHOOK_ALLOWED_HOSTS"), ","),
+	)
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if raw := os.Getenv(key); raw != "" {
+		if v, err := time.ParseDuration(raw); err == nil && v > 0 {
+			return v
+		}
+	}
+	return fallback
+}
+
 func envInt(key string, fallback int) int {
 	if raw := os.Getenv(key); raw != "" {
 		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
@@ -7,15 +7,23 @@
 package app_test
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/AndriyKalashnykov/flight-path/internal/app"
+	"github.com/AndriyKalashnykov/flight-path/internal/webhook"
 )
 
 func newTestServer(t *testing.T, env map[string]string) *httptest.Server {
@@ -23,7 +31,11 @@ func newTestServer(t *testing.T, env map[string]string) *httptest.Server {
 	for k, v := range env {
 		t.Setenv(k, v)
 	}
-	s := httptest.NewServer(app.New())
+	e, err := app.New()
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	s := httptest.NewServer(e)
 	t.Cleanup(s.Close)
 	return s
 }
@@ -140,6 +152,34 @@ func TestCalculateHappyPath(t *testing.T) {
 	}
 }
 
+// TestCalculateItineraryHappyPath asserts POST /calculate/itinerary returns
+// every airport of the path in travel order, not just the endpoints.
+func TestCalculateItineraryHappyPath(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["IND","EWR"],["SFO","ATL"],["GSO","IND"],["ATL","GSO"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/itinerary", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		Path []string
+		Legs []map[string]string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	want := []string{"SFO", "ATL", "GSO", "IND", "EWR"}
+	if !slices.Equal(got.Path, want) {
+		t.Errorf("Path: want %v, got %v", want, got.Path)
+	}
+	if len(got.Legs) != 4 || got.Legs[0]["Start"] != "SFO" || got.Legs[3]["End"] != "EWR" {
+		t.Errorf("Legs: want 4 ordered legs SFO..EWR, got %v", got.Legs)
+	}
+}
+
 func TestCalculateEmptyArray(t *testing.T) {
 	s := newTestServer(t, nil)
 	body := bytes.NewBufferString(`[]`)
@@ -261,6 +301,37 @@ func TestCalculateDisconnectedGraph(t *testing.T) {
 	if msg, _ := env["Error"].(string); !strings.Contains(strings.ToLower(msg), "disconnected") {
 		t.Errorf("Error: want substring 'disconnected', got %q", msg)
 	}
+	if got, _ := env["StartCandidates"].([]any); len(got) != 2 || got[0] != "A" || got[1] != "C" {
+		t.Errorf("StartCandidates: want [A C], got %v", env["StartCandidates"])
+	}
+	if got, _ := env["EndCandidates"].([]any); len(got) != 2 || got[0] != "B" || got[1] != "D" {
+		t.Errorf("EndCandidates: want [B D], got %v", env["EndCandidates"])
+	}
+}
+
+// TestCalculateDetachedCycle asserts that a cycle sitting beside an otherwise
+// valid path is rejected as disconnected, with the leftover component in the
+// body, instead of silently returning the path's endpoints.
+func TestCalculateDetachedCycle(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["A","B"],["B","C"],["D","E"],["E","D"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("detached cycle: want 400, got %d", resp.StatusCode)
+	}
+	var env map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if msg, _ := env["Error"].(string); !strings.Contains(strings.ToLower(msg), "disconnected") {
+		t.Errorf("Error: want substring 'disconnected', got %q", msg)
+	}
+	if got, _ := env["Components"].([]any); len(got) != 1 {
+		t.Errorf("Components: want 1 leftover component, got %v", env["Components"])
+	}
 }
 
 // TestCalculateCircularPath asserts that circular inputs (every airport has
@@ -283,6 +354,735 @@ func TestCalculateCircularPath(t *testing.T) {
 	if msg, _ := env["Error"].(string); !strings.Contains(strings.ToLower(msg), "circular") {
 		t.Errorf("Error: want substring 'circular', got %q", msg)
 	}
+	if got, _ := env["Airports"].([]any); len(got) != 2 {
+		t.Errorf("Airports: want the 2 airports of the loop, got %v", env["Airports"])
+	}
+	if got, _ := env["Indexes"].([]any); len(got) != 2 {
+		t.Errorf("Indexes: want both segment indexes, got %v", env["Indexes"])
+	}
+}
+
+// TestCalculateBranchingPath asserts that an airport with two outgoing flights
+// is rejected with 400 even though the input has a unique start and end, and
+// that the body names the airport and the segments leaving it.
+func TestCalculateBranchingPath(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["A","B"],["A","C"],["B","C"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("branching path: want 400, got %d", resp.StatusCode)
+	}
+	var env map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if msg, _ := env["Error"].(string); !strings.Contains(strings.ToLower(msg), "branching") {
+		t.Errorf("Error: want substring 'branching', got %q", msg)
+	}
+	if got, _ := env["Airport"].(string); got != "A" {
+		t.Errorf("Airport: want A, got %v", env["Airport"])
+	}
+	if got, _ := env["Indexes"].([]any); len(got) != 2 {
+		t.Errorf("Indexes: want 2 segment indexes, got %v", env["Indexes"])
+	}
+}
+
+// TestCalculateRoundTripWithAnchor asserts that a round trip, which is
+// rejected as circular on its own, is solved once the caller names the home
+// airport via the anchor query parameter.
+func TestCalculateRoundTripWithAnchor(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["JFK","SFO"],["SFO","JFK"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate?anchor=SFO", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("round trip with anchor: want 200, got %d", resp.StatusCode)
+	}
+	var got []string
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	want := []string{"SFO", "SFO"}
+	if !slices.Equal(got, want) {
+		t.Errorf("body: want %v, got %v", want, got)
+	}
+}
+
+// TestCalculateEulerianMode asserts that mode=eulerian accepts an itinerary
+// that revisits a hub, and that an impossible multigraph names the unbalanced
+// airports in the 400 body.
+func TestCalculateEulerianMode(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["A","B"],["B","C"],["C","B"],["B","D"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate?mode=eulerian", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("eulerian mode: want 200, got %d", resp.StatusCode)
+	}
+	var got []string
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if want := []string{"A", "D"}; !slices.Equal(got, want) {
+		t.Errorf("body: want %v, got %v", want, got)
+	}
+
+	body = bytes.NewBufferString(`[["A","B"],["A","C"]]`)
+	req = must(http.NewRequest(http.MethodPost, s.URL+"/calculate?mode=eulerian", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp2 := do(t, req)
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unbalanced eulerian input: want 400, got %d", resp2.StatusCode)
+	}
+	var env map[string]any
+	if err := json.NewDecoder(resp2.Body).Decode(&env); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got, _ := env["Airports"].([]any); len(got) != 3 {
+		t.Errorf("Airports: want 3 unbalanced airports, got %v", env["Airports"])
+	}
+}
+
+// TestCalculateComponents asserts that a payload holding two separate trips is
+// split into one component per trip instead of failing as disconnected.
+func TestCalculateComponents(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["A","B"],["C","D"],["B","E"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/components", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("components: want 200, got %d", resp.StatusCode)
+	}
+	var got []struct {
+		Start, End string
+		Indexes    []int
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 components, got %d: %v", len(got), got)
+	}
+	if got[0].Start != "A" || got[0].End != "E" || !slices.Equal(got[0].Indexes, []int{0, 2}) {
+		t.Errorf("component 0: want A->E over [0 2], got %+v", got[0])
+	}
+	if got[1].Start != "C" || got[1].End != "D" || !slices.Equal(got[1].Indexes, []int{1}) {
+		t.Errorf("component 1: want C->D over [1], got %+v", got[1])
+	}
+}
+
+// TestCalculateGaps asserts that a payload missing one ticket gets a single
+// bridging segment suggested between the two fragments.
+func TestCalculateGaps(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["IND","EWR"],["SFO","ATL"],["GSO","IND"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/gaps", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("gaps: want 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		Fragments [][]string
+		Bridges   []map[string]string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(got.Fragments) != 2 {
+		t.Errorf("Fragments: want 2, got %v", got.Fragments)
+	}
+	if len(got.Bridges) != 1 || got.Bridges[0]["Start"] != "EWR" || got.Bridges[0]["End"] != "SFO" {
+		t.Errorf("Bridges: want [EWR->SFO], got %v", got.Bridges)
+	}
+}
+
+// TestCalculateTimedRoundTrip asserts fully timed segments are ordered by
+// departure, so a round trip resolves without an anchor.
+func TestCalculateTimedRoundTrip(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["EWR","SFO","2026-03-05T18:00:00Z","2026-03-05T21:30:00Z"],` +
+		`["SFO","EWR","2026-03-01T08:00:00Z","2026-03-01T16:30:00Z"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("timed round trip: want 200, got %d", resp.StatusCode)
+	}
+	var got []string
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(got) != 2 || got[0] != "SFO" || got[1] != "SFO" {
+		t.Errorf("want [SFO SFO], got %v", got)
+	}
+}
+
+// TestCalculateChronologyConflict asserts a connection departing before the
+// previous leg lands is rejected with 400 + Indexes naming both legs.
+func TestCalculateChronologyConflict(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["ATL","EWR","2026-03-01T10:00:00Z"],` +
+		`["SFO","ATL","2026-03-01T06:00:00Z","2026-03-01T11:00:00Z"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("chronology conflict: want 400, got %d", resp.StatusCode)
+	}
+	var env map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if msg, _ := env["Error"].(string); !strings.HasPrefix(msg, "chronology conflict") {
+		t.Errorf("Error: want chronology conflict, got %q", msg)
+	}
+	if idx, _ := env["Indexes"].([]any); len(idx) != 2 || idx[0] != float64(1) || idx[1] != float64(0) {
+		t.Errorf("Indexes: want [1 0], got %v", env["Indexes"])
+	}
+}
+
+// TestCalculateSummary asserts the distance summary is served through the
+// full middleware chain with totals in every unit.
+func TestCalculateSummary(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["ATL","EWR"],["SFO","ATL"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/summary", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("summary: want 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		Legs        []map[string]any
+		Total       map[string]float64
+		Direct      map[string]float64
+		DetourRatio float64
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(got.Legs) != 2 || got.Total["Km"] <= got.Direct["Km"] || got.Total["Mi"] == 0 || got.Total["Nm"] == 0 {
+		t.Errorf("unexpected summary: %+v", got)
+	}
+	if got.DetourRatio <= 1 {
+		t.Errorf("DetourRatio: want > 1, got %v", got.DetourRatio)
+	}
+}
+
+// TestCalculateEmissions asserts the emissions endpoint honours the cabin
+// query parameter: business is estimated higher than economy.
+func TestCalculateEmissions(t *testing.T) {
+	s := newTestServer(t, nil)
+	total := func(cabin string) float64 {
+		t.Helper()
+		body := bytes.NewBufferString(`[["JFK","LHR"]]`)
+		req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/emissions?cabin="+cabin, body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := do(t, req)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("emissions (%s): want 200, got %d", cabin, resp.StatusCode)
+		}
+		var got struct {
+			Cabin      string
+			TotalKgCO2 float64
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if got.Cabin != cabin {
+			t.Errorf("Cabin: want %s, got %s", cabin, got.Cabin)
+		}
+		return got.TotalKgCO2
+	}
+	if economy, business := total("economy"), total("business"); economy <= 0 || business <= economy {
+		t.Errorf("want 0 < economy < business, got %v and %v", economy, business)
+	}
+}
+
+// TestCalculateCountries asserts the country report flags an international
+// itinerary and lists its crossings.
+func TestCalculateCountries(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := bytes.NewBufferString(`[["CDG","JFK"],["JFK","CDG"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/countries?anchor=JFK", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("countries: want 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		Countries []string
+		Crossings []map[string]string
+		Domestic  bool
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if !slices.Equal(got.Countries, []string{"US", "FR", "US"}) || len(got.Crossings) != 2 || got.Domestic {
+		t.Errorf("unexpected report: %+v", got)
+	}
+}
+
+// TestCalculateBatch asserts a batch with one bad passenger still returns 200
+// with per-ID results.
+func TestCalculateBatch(t *testing.T) {
+	s := newTestServer(t, map[string]string{"BATCH_WORKERS": "2"})
+	body := bytes.NewBufferString(`{"alice":[["ATL","EWR"],["SFO","ATL"]],"bob":[["A","B"],["C","D"]]}`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/batch", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch: want 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		Results           map[string]map[string]any
+		Succeeded, Failed int
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.Succeeded != 1 || got.Failed != 1 {
+		t.Errorf("Succeeded/Failed: want 1/1, got %d/%d", got.Succeeded, got.Failed)
+	}
+	if got.Results["alice"]["Start"] != "SFO" || got.Results["alice"]["End"] != "EWR" {
+		t.Errorf("alice: want SFO -> EWR, got %v", got.Results["alice"])
+	}
+	if msg, _ := got.Results["bob"]["Error"].(string); !strings.HasPrefix(msg, "disconnected graph") {
+		t.Errorf("bob: want disconnected graph error, got %v", got.Results["bob"])
+	}
+}
+
+// TestCalculateStream asserts the NDJSON endpoint answers each line while the
+// request is still open and accepts a body well past the 1 MiB limit.
+func TestCalculateStream(t *testing.T) {
+	s := newTestServer(t, nil)
+	pr, pw := io.Pipe()
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/stream", pr))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	respc := make(chan *http.Response, 1)
+	go func() { respc <- do(t, req) }()
+
+	if _, err := io.WriteString(pw, `[["ATL","EWR"],["SFO","ATL"]]`+"\n"); err != nil {
+		t.Fatalf("write first line: %v", err)
+	}
+	resp := <-respc
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stream: want 200, got %d", resp.StatusCode)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || !strings.Contains(lines.Text(), `"Start":"SFO","End":"EWR"`) {
+		t.Fatalf("first line answered before EOF: want SFO -> EWR, got %q (%v)", lines.Text(), lines.Err())
+	}
+
+	const bulk = 50000 // ~1.1 MiB
+	go func() {
+		for range bulk {
+			_, _ = io.WriteString(pw, `[["JFK","LHR"]]`+"\n")
+		}
+		pw.Close()
+	}()
+	n := 0
+	for lines.Scan() {
+		n++
+	}
+	if n != bulk {
+		t.Errorf("bulk lines: want %d results, got %d (%v)", bulk, n, lines.Err())
+	}
+}
+
+// TestJobsSubmitPollExpire asserts a job submitted to POST /jobs can be
+// polled to its result and is forgotten once JOB_TTL has passed.
+func TestJobsSubmitPollExpire(t *testing.T) {
+	s := newTestServer(t, map[string]string{"JOB_WORKERS": "1", "JOB_TTL": "200ms"})
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/jobs", bytes.NewBufferString(`[["ATL","EWR"],["SFO","ATL"]]`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("submit: want 202, got %d", resp.StatusCode)
+	}
+	var job struct {
+		ID, Status string
+		Result     map[string]any
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	location := s.URL + resp.Header.Get("Location")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != "succeeded" {
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not succeed: %+v", job)
+		}
+		poll := do(t, must(http.NewRequest(http.MethodGet, location, nil)))
+		if err := json.NewDecoder(poll.Body).Decode(&job); err != nil {
+			t.Fatalf("decode poll: %v", err)
+		}
+		poll.Body.Close()
+	}
+	if job.Result["Start"] != "SFO" || job.Result["End"] != "EWR" {
+		t.Errorf("result: want SFO -> EWR, got %v", job.Result)
+	}
+
+	for {
+		poll := do(t, must(http.NewRequest(http.MethodGet, location, nil)))
+		poll.Body.Close()
+		if poll.StatusCode == http.StatusNotFound {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still served after JOB_TTL: %d", poll.StatusCode)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// TestBatchCallbackDelivered asserts a batch submitted with a callback is
+// queued as a job, POSTed to a local receiver with a valid signature after a
+// failed first attempt, and that both attempts are visible on GET /jobs/{id}.
+func TestBatchCallbackDelivered(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(webhook.SignatureHeader) != webhook.Sign([]byte("s3cret"), body) {
+			t.Errorf("signature %q does not match the body", r.Header.Get(webhook.SignatureHeader))
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var job map[string]any
+		_ = json.Unmarshal(body, &job)
+		received <- job
+	}))
+	defer receiver.Close()
+	s := newTestServer(t, map[string]string{"WEBHOOK_SECRET": "s3cret", "WEBHOOK_BACKOFF": "1ms", "WEBHOOK_ALLOWED_HOSTS": "127.0.0.1"})
+
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate/batch?callback="+url.QueryEscape(receiver.URL),
+		bytes.NewBufferString(`{"alice":[["ATL","EWR"],["SFO","ATL"]]}`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("batch with callback: want 202, got %d", resp.StatusCode)
+	}
+
+	select {
+	case job := <-received:
+		batch, _ := job["Batch"].(map[string]any)
+		if job["Status"] != "succeeded" || batch["Succeeded"] != float64(1) {
+			t.Errorf("delivered job: want a succeeded batch, got %v", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not received")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		poll := do(t, must(http.NewRequest(http.MethodGet, s.URL+resp.Header.Get("Location"), nil)))
+		var job struct {
+			Callback struct {
+				Status   string
+				Attempts []struct{ StatusCode int }
+			}
+		}
+		_ = json.NewDecoder(poll.Body).Decode(&job)
+		poll.Body.Close()
+		if job.Callback.Status == "delivered" {
+			if len(job.Callback.Attempts) != 2 || job.Callback.Attempts[0].StatusCode != http.StatusInternalServerError {
+				t.Errorf("attempts: want a 500 then a success, got %+v", job.Callback.Attempts)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("callback status: want delivered, got %+v", job.Callback)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestItinerariesPersistAcrossRestart asserts an itinerary saved with
+// STORE_PATH set is still listed and served by a second server opened on the
+// same bbolt file.
+func TestItinerariesPersistAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight-path.db")
+	env := map[string]string{"STORE_PATH": path}
+	s := newTestServer(t, env)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/itineraries", bytes.NewBufferString(`[["ATL","EWR"],["SFO","ATL"]]`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("save: want 201, got %d", resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	s.Close()
+	// bbolt holds an exclusive file lock until the store is closed; the
+	// first server's store is never closed, so copy the file to reopen it.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	env["STORE_PATH"] = path + ".copy"
+	if err := os.WriteFile(env["STORE_PATH"], data, 0o600); err != nil {
+		t.Fatalf("copy store: %v", err)
+	}
+
+	s = newTestServer(t, env)
+	resp = do(t, must(http.NewRequest(http.MethodGet, s.URL+location, nil)))
+	defer resp.Body.Close()
+	var it struct {
+		Path []string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&it); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("get after restart: %d, %v", resp.StatusCode, err)
+	}
+	if !slices.Equal(it.Path, []string{"SFO", "ATL", "EWR"}) {
+		t.Errorf("Path: want [SFO ATL EWR], got %v", it.Path)
+	}
+
+	list := do(t, must(http.NewRequest(http.MethodGet, s.URL+"/itineraries?limit=1", nil)))
+	defer list.Body.Close()
+	var page struct{ Total, Limit int }
+	if err := json.NewDecoder(list.Body).Decode(&page); err != nil || page.Total != 1 || page.Limit != 1 {
+		t.Errorf("list: want Total 1 Limit 1, got %+v (%v)", page, err)
+	}
+}
+
+// TestPassengerLocation asserts segments appended to a passenger one request
+// at a time are reconstructed in departure order by the location query.
+func TestPassengerLocation(t *testing.T) {
+	s := newTestServer(t, nil)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/passengers", bytes.NewBufferString(`{"name":"Alice"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: want 201, got %d", resp.StatusCode)
+	}
+	passenger := resp.Header.Get("Location")
+
+	for _, segment := range []string{
+		`[["ATL","EWR","2026-03-01T13:00:00Z","2026-03-01T15:00:00Z"]]`,
+		`[["SFO","ATL","2026-03-01T06:00:00Z","2026-03-01T11:00:00Z"]]`,
+	} {
+		req := must(http.NewRequest(http.MethodPost, s.URL+passenger+"/segments", bytes.NewBufferString(segment)))
+		req.Header.Set("Content-Type", "application/json")
+		resp := do(t, req)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("append %s: want 200, got %d", segment, resp.StatusCode)
+		}
+	}
+
+	resp = do(t, must(http.NewRequest(http.MethodGet, s.URL+passenger+"/location?at=2026-03-01T12:00:00Z", nil)))
+	defer resp.Body.Close()
+	var loc struct{ Status, Airport string }
+	if err := json.NewDecoder(resp.Body).Decode(&loc); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("location: %d, %v", resp.StatusCode, err)
+	}
+	if loc.Status != "at_airport" || loc.Airport != "ATL" {
+		t.Errorf("location: want at_airport ATL, got %+v", loc)
+	}
+}
+
+// TestContactTraceAfterRestart asserts two passengers on the same flight are
+// linked by GET /passengers/{id}/contacts, including on a second server
+// whose contact index is rebuilt from the same bbolt file.
+func TestContactTraceAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight-path.db")
+	env := map[string]string{"STORE_PATH": path}
+	s := newTestServer(t, env)
+	for range 2 {
+		resp := do(t, must(http.NewRequest(http.MethodPost, s.URL+"/passengers", nil)))
+		resp.Body.Close()
+		req := must(http.NewRequest(http.MethodPost, s.URL+resp.Header.Get("Location")+"/segments",
+			bytes.NewBufferString(`{"segments":[{"from":"SFO","to":"ATL","flight":"DL1","departs":"2026-03-01T06:00:00Z","arrives":"2026-03-01T11:00:00Z"}]}`)))
+		req.Header.Set("Content-Type", "application/json")
+		resp = do(t, req)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("append: want 200, got %d", resp.StatusCode)
+		}
+	}
+	s.Close()
+	// See TestItinerariesPersistAcrossRestart for why the file is copied.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	env["STORE_PATH"] = path + ".copy"
+	if err := os.WriteFile(env["STORE_PATH"], data, 0o600); err != nil {
+		t.Fatalf("copy store: %v", err)
+	}
+
+	s = newTestServer(t, env)
+	resp := do(t, must(http.NewRequest(http.MethodGet, s.URL+"/passengers/1/contacts", nil)))
+	defer resp.Body.Close()
+	var trace struct {
+		Contacts []struct {
+			Passenger string
+			Flights   []struct{ Number string }
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&trace); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("contacts: %d, %v", resp.StatusCode, err)
+	}
+	if len(trace.Contacts) != 1 || trace.Contacts[0].Passenger != "2" || len(trace.Contacts[0].Flights) != 1 {
+		t.Errorf("contacts: want passenger 2 on DL1, got %+v", trace.Contacts)
+	}
+}
+
+// TestSessionAssembledIncrementally asserts segments POSTed one by one to a
+// session are reflected in GET /sessions/{id}: disconnected while a gap
+// remains, then a valid path once it is filled; DELETE then closes it.
+func TestSessionAssembledIncrementally(t *testing.T) {
+	s := newTestServer(t, nil)
+	resp := do(t, must(http.NewRequest(http.MethodPost, s.URL+"/sessions", nil)))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: want 201, got %d", resp.StatusCode)
+	}
+	session := resp.Header.Get("Location")
+
+	steps := []struct {
+		segment    string
+		wantStatus string
+	}{
+		{`{"from":"ATL","to":"EWR"}`, "valid"},
+		{`{"from":"SFO","to":"ORD"}`, "disconnected"},
+		{`{"from":"ORD","to":"ATL"}`, "valid"},
+	}
+	for _, step := range steps {
+		req := must(http.NewRequest(http.MethodPost, s.URL+session+"/segments", bytes.NewBufferString(step.segment)))
+		req.Header.Set("Content-Type", "application/json")
+		resp := do(t, req)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("append %s: want 200, got %d", step.segment, resp.StatusCode)
+		}
+
+		get := do(t, must(http.NewRequest(http.MethodGet, s.URL+session, nil)))
+		var state struct{ Status, Start, End string }
+		err := json.NewDecoder(get.Body).Decode(&state)
+		get.Body.Close()
+		if err != nil || state.Status != step.wantStatus {
+			t.Fatalf("after %s: want %s, got %+v (%v)", step.segment, step.wantStatus, state, err)
+		}
+		if step.wantStatus == "valid" && state.End != "EWR" {
+			t.Errorf("after %s: want End EWR, got %+v", step.segment, state)
+		}
+	}
+
+	resp = do(t, must(http.NewRequest(http.MethodDelete, s.URL+session, nil)))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: want 204, got %d", resp.StatusCode)
+	}
+	resp = do(t, must(http.NewRequest(http.MethodGet, s.URL+session, nil)))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get after delete: want 404, got %d", resp.StatusCode)
+	}
+}
+
+// TestItineraryPatchVersions asserts a JSON Patch sent with the ETag of a
+// bbolt-backed itinerary saves a new version, that replaying it against the
+// old ETag fails with 412, and that both versions are listed.
+func TestItineraryPatchVersions(t *testing.T) {
+	s := newTestServer(t, map[string]string{"STORE_PATH": filepath.Join(t.TempDir(), "flight-path.db")})
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/itineraries", bytes.NewBufferString(`[["SFO","ATL"],["ATL","EWR"]]`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("save: want 201, got %d", resp.StatusCode)
+	}
+	location, etag := resp.Header.Get("Location"), resp.Header.Get("ETag")
+
+	patch := `[{"op":"replace","path":"/Segments/1/End","value":"BOS"}]`
+	for _, want := range []int{http.StatusOK, http.StatusPreconditionFailed} {
+		req := must(http.NewRequest(http.MethodPatch, s.URL+location, bytes.NewBufferString(patch)))
+		req.Header.Set("Content-Type", "application/json-patch+json")
+		req.Header.Set("If-Match", etag)
+		resp := do(t, req)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("patch: want %d, got %d", want, resp.StatusCode)
+		}
+		if resp.Header.Get("ETag") != `"2"` {
+			t.Errorf("patch: want ETag \"2\", got %q", resp.Header.Get("ETag"))
+		}
+	}
+
+	resp = do(t, must(http.NewRequest(http.MethodGet, s.URL+location+"/versions", nil)))
+	defer resp.Body.Close()
+	var history struct {
+		Versions []struct {
+			Version int
+			End     string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("versions: %d, %v", resp.StatusCode, err)
+	}
+	if len(history.Versions) != 2 || history.Versions[0].End != "EWR" || history.Versions[1].End != "BOS" {
+		t.Errorf("versions: want EWR then BOS, got %+v", history.Versions)
+	}
+}
+
+// TestCalculateMergesSources asserts SOURCE_PRIORITY decides which of two
+// conflicting records of the same flight POST /calculate keeps, and that the
+// response reports the merge.
+func TestCalculateMergesSources(t *testing.T) {
+	s := newTestServer(t, map[string]string{"SOURCE_PRIORITY": "airline,expense"})
+	body := bytes.NewBufferString(`{"segments":[
+		{"from":"SFO","to":"ORD","flight":"UA5","departs":"2026-03-01T06:00:00Z","source":"expense"},
+		{"from":"SFO","to":"ATL","flight":"UA5","departs":"2026-03-01T07:00:00Z","source":"airline"},
+		{"from":"ATL","to":"EWR","source":"expense"}
+	]}`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	var got struct {
+		Start, End string
+		Merges     []struct {
+			Kept      int
+			Source    string
+			Discarded []int
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("calculate: %d, %v", resp.StatusCode, err)
+	}
+	if got.Start != "SFO" || got.End != "EWR" {
+		t.Errorf("want SFO -> EWR, got %s -> %s", got.Start, got.End)
+	}
+	if len(got.Merges) != 1 || got.Merges[0].Source != "airline" || !slices.Equal(got.Merges[0].Discarded, []int{0}) {
+		t.Errorf("Merges: want the expense record discarded for the airline one, got %+v", got.Merges)
+	}
 }
 
 // TestCalculateSelfLoopRejected asserts a segment whose source equals its
@@ -310,6 +1110,27 @@ func TestCalculateSelfLoopRejected(t *testing.T) {
 	}
 }
 
+// TestCalculateStrictAirportsRejectsUnknown asserts AIRPORT_VALIDATION=strict
+// rejects a code missing from the embedded registry with 400 + Index.
+func TestCalculateStrictAirportsRejectsUnknown(t *testing.T) {
+	s := newTestServer(t, map[string]string{"AIRPORT_VALIDATION": "strict"})
+	body := bytes.NewBufferString(`[["SFO","ATL"],["ATL","XXX"]]`)
+	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := do(t, req)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("strict airports: want 400, got %d", resp.StatusCode)
+	}
+	var env map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if env["Index"] != float64(1) || env["Airport"] != "XXX" {
+		t.Errorf("want Index 1 and Airport XXX, got %v", env)
+	}
+}
+
 // TestCalculateEmptyAirportRejected asserts a segment containing an empty
 // airport code is rejected with 400 + Index rather than silently producing a
 // success response with an empty-string airport.
@@ -409,8 +1230,8 @@ func TestCalculateEmptyBody(t *testing.T) {
 	}
 }
 
-// TestCalculateObjectRoot mirrors Newman UseCase09: a JSON object root instead
-// of an array — must fail bind with a parse-style error.
+// TestCalculateObjectRoot mirrors Newman UseCase09: a JSON object root that is
+// not a CalculateRequest (unknown fields) — must fail with a parse-style error.
 func TestCalculateObjectRoot(t *testing.T) {
 	s := newTestServer(t, nil)
 	body := bytes.NewBufferString(`{"foo":"bar"}`)
@@ -431,25 +1252,58 @@ func TestCalculateObjectRoot(t *testing.T) {
 	}
 }
 
-// TestCalculateExtraItemsIgnored mirrors Newman UseCase07: extra elements
-// past the second in a segment must be silently ignored — first two used.
-func TestCalculateExtraItemsIgnored(t *testing.T) {
+// TestCalculateObjectBody asserts the versioned object form is accepted
+// alongside the legacy array, with options applied from the body, and is
+// answered with an object listing the normalized airport codes.
+func TestCalculateObjectBody(t *testing.T) {
 	s := newTestServer(t, nil)
-	body := bytes.NewBufferString(`[["SFO","EWR","JFK"]]`)
+	body := bytes.NewBufferString(`{"version":1,"segments":[{"from":"sfo","to":"KEWR","flight":"UA1"},` +
+		`{"from":"EWR","to":"SFO","flight":"UA2"}],"options":{"anchor":"SFO"}}`)
 	req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", body))
 	req.Header.Set("Content-Type", "application/json")
 	resp := do(t, req)
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("want 200, got %d", resp.StatusCode)
+		t.Fatalf("object body: want 200, got %d", resp.StatusCode)
+	}
+	var got struct {
+		Start, End string
+		Rewrites   []map[string]any
 	}
-	var got []string
 	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
 		t.Fatalf("decode body: %v", err)
 	}
-	want := []string{"SFO", "EWR"}
-	if !slices.Equal(got, want) {
-		t.Errorf("body: want %v, got %v", want, got)
+	if got.Start != "SFO" || got.End != "SFO" {
+		t.Errorf("want SFO -> SFO, got %s -> %s", got.Start, got.End)
+	}
+	if len(got.Rewrites) != 2 || got.Rewrites[1]["From"] != "KEWR" || got.Rewrites[1]["To"] != "EWR" {
+		t.Errorf("Rewrites: want sfo->SFO and KEWR->EWR, got %v", got.Rewrites)
+	}
+}
+
+// TestCalculateExtraItemsIgnored mirrors Newman UseCase07: extra elements
+// past the second in a segment must be silently ignored — first two used —
+// unless they are RFC 3339 timestamps.
+func TestCalculateExtraItemsIgnored(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, payload := range []string{`[["SFO","EWR","JFK"]]`, `[["SFO","EWR","123"]]`} {
+		req := must(http.NewRequest(http.MethodPost, s.URL+"/calculate", bytes.NewBufferString(payload)))
+		req.Header.Set("Content-Type", "application/json")
+		resp := do(t, req)
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			t.Fatalf("%s: want 200, got %d", payload, resp.StatusCode)
+		}
+		var got []string
+		err := json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: decode body: %v", payload, err)
+		}
+		want := []string{"SFO", "EWR"}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: body: want %v, got %v", payload, want, got)
+		}
 	}
 }
 
@@ -0,0 +1,279 @@
+// Package contacts indexes passengers' timed segments by flight and by
+// airport so that everyone who travelled alongside a given passenger can be
+// found without scanning every stored record.
+package contacts
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// Bounds of the window within which two airport stays count as shared.
+const (
+	DefaultWindow = 2 * time.Hour
+	MaxWindow     = 24 * time.Hour
+)
+
+// maxConnection is the longest connection counted as one airport stay;
+// after a longer one the passenger is taken to have left the airport, and
+// only the landing and the next departure count. It also bounds the slots a
+// stay is listed under.
+const maxConnection = 24 * time.Hour
+
+// slot is the width of the time buckets of the airport index. A stay is
+// listed under every slot it touches.
+const slot = time.Hour
+
+// flightKey identifies one operated flight: the flight number, upper-cased
+// without spaces, and the departure date in the origin airport's time zone,
+// so the key does not depend on the offset a timestamp was written in.
+type flightKey struct {
+	number string
+	date   string
+}
+
+// slotKey identifies one airport during one slot, by the slot's Unix start.
+type slotKey struct {
+	airport string
+	slot    int64
+}
+
+// entry is what the index holds for one passenger.
+type entry struct {
+	legs  []api.Flight // timed segments in departure order
+	keys  []flightKey  // per leg; zero for a leg without a flight number
+	stays []api.Stay
+}
+
+// Index maps (flight number, date) and (airport, time slot) to the
+// passengers found there. It is safe for concurrent use.
+type Index struct {
+	registry *airports.Registry
+
+	mu         sync.RWMutex
+	passengers map[string]entry
+	flights    map[flightKey]map[string]struct{}
+	airports   map[slotKey]map[string]struct{}
+}
+
+// New returns an empty Index that dates flights in the time zones of reg.
+func New(reg *airports.Registry) *Index {
+	return &Index{
+		registry:   reg,
+		passengers: make(map[string]entry),
+		flights:    make(map[flightKey]map[string]struct{}),
+		airports:   make(map[slotKey]map[string]struct{}),
+	}
+}
+
+// Load returns an Index of every passenger in s, dating flights in the time
+// zones of reg.
+func Load(s store.Passengers, reg *airports.Registry) (*Index, error) {
+	passengers, err := s.Passengers()
+	if err != nil {
+		return nil, err
+	}
+	x := New(reg)
+	for _, p := range passengers {
+		x.Set(p.ID, p.Segments)
+	}
+	return x, nil
+}
+
+// Set indexes the segments of passenger id, replacing what was indexed for
+// it before. Segments without a departure time are skipped. A passenger's
+// segments only ever grow, so a list no longer than the one already indexed
+// is ignored; concurrent appends may therefore call Set in any order.
+func (x *Index) Set(id string, segments []api.Flight) {
+	legs := make([]api.Flight, 0, len(segments))
+	for _, f := range segments {
+		if !f.Departure.IsZero() {
+			legs = append(legs, f)
+		}
+	}
+	slices.SortStableFunc(legs, func(a, b api.Flight) int {
+		return a.Departure.Compare(b.Departure)
+	})
+	next := entry{legs: legs, keys: make([]flightKey, len(legs)), stays: stays(legs)}
+	for i, f := range legs {
+		next.keys[i] = x.keyOf(f)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	prev := x.passengers[id]
+	if len(prev.legs) >= len(next.legs) {
+		return
+	}
+	for _, k := range prev.flightKeys() {
+		unlink(x.flights, k, id)
+	}
+	for _, k := range prev.slotKeys(0) {
+		unlink(x.airports, k, id)
+	}
+	x.passengers[id] = next
+	for _, k := range next.flightKeys() {
+		link(x.flights, k, id)
+	}
+	for _, k := range next.slotKeys(0) {
+		link(x.airports, k, id)
+	}
+}
+
+// Trace returns every other passenger who flew the same flight number on the
+// same date as passenger id, or whose airport stay came within window of one
+// of theirs, ordered by passenger ID. Each contact lists only what it shares.
+// Candidates come from the index buckets, so the cost is proportional to the
+// passengers met rather than to the number indexed.
+func (x *Index) Trace(id string, window time.Duration) []api.Contact {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	traced := x.passengers[id]
+
+	candidates := make(map[string]struct{})
+	for _, k := range traced.flightKeys() {
+		for other := range x.flights[k] {
+			candidates[other] = struct{}{}
+		}
+	}
+	for _, k := range traced.slotKeys(window) {
+		for other := range x.airports[k] {
+			candidates[other] = struct{}{}
+		}
+	}
+	delete(candidates, id)
+
+	found := make([]api.Contact, 0, len(candidates))
+	for other := range candidates {
+		c := shared(traced, x.passengers[other], window)
+		if len(c.Flights) > 0 || len(c.Stays) > 0 {
+			c.Passenger = other
+			found = append(found, c)
+		}
+	}
+	// IDs are decimal sequences, so the shorter one is always the smaller.
+	slices.SortFunc(found, func(a, b api.Contact) int {
+		return cmp.Or(cmp.Compare(len(a.Passenger), len(b.Passenger)), strings.Compare(a.Passenger, b.Passenger))
+	})
+	return found
+}
+
+// shared returns what other shares with traced: its legs on a flight traced
+// also took, and its stays within window of one of traced's at the same
+// airport.
+func shared(traced, other entry, window time.Duration) api.Contact {
+	var c api.Contact
+	flights := make(map[flightKey]struct{}, len(traced.legs))
+	for _, k := range traced.flightKeys() {
+		flights[k] = struct{}{}
+	}
+	for i, f := range other.legs {
+		if _, hit := flights[other.keys[i]]; hit && other.keys[i] != (flightKey{}) {
+			c.Flights = append(c.Flights, f)
+		}
+	}
+	for _, s := range other.stays {
+		if slices.ContainsFunc(traced.stays, func(t api.Stay) bool { return overlaps(t, s, window) }) {
+			c.Stays = append(c.Stays, s)
+		}
+	}
+	return c
+}
+
+// overlaps reports whether stays a and b are at the same airport with at
+// most window between them.
+func overlaps(a, b api.Stay, window time.Duration) bool {
+	return a.Airport == b.Airport &&
+		!b.From.After(a.To.Add(window)) &&
+		!a.From.After(b.To.Add(window))
+}
+
+// stays derives the airport stays of legs flown in order: the origin at the
+// first departure, each connection from landing to the next departure, and
+// the final destination at the last arrival. A connection longer than
+// maxConnection becomes two instants, and a leg without an arrival time
+// counts as landing when it departed.
+func stays(legs []api.Flight) []api.Stay {
+	if len(legs) == 0 {
+		return nil
+	}
+	out := make([]api.Stay, 0, len(legs)+1)
+	first := legs[0]
+	out = append(out, api.Stay{Airport: first.Start, From: first.Departure, To: first.Departure})
+	for i := 1; i < len(legs); i++ {
+		from, to := landed(legs[i-1]), legs[i].Departure
+		if to.Sub(from) > maxConnection {
+			out = append(out,
+				api.Stay{Airport: legs[i].Start, From: from, To: from},
+				api.Stay{Airport: legs[i].Start, From: to, To: to})
+			continue
+		}
+		out = append(out, api.Stay{Airport: legs[i].Start, From: from, To: to})
+	}
+	last := legs[len(legs)-1]
+	return append(out, api.Stay{Airport: last.End, From: landed(last), To: landed(last)})
+}
+
+// landed returns when f arrived, falling back to its departure.
+func landed(f api.Flight) time.Time {
+	if f.Arrival.IsZero() {
+		return f.Departure
+	}
+	return f.Arrival
+}
+
+// keyOf returns the flight key of the timed leg f, or the zero key when f
+// has no flight number.
+func (x *Index) keyOf(f api.Flight) flightKey {
+	number := strings.ToUpper(strings.ReplaceAll(f.Number, " ", ""))
+	if number == "" {
+		return flightKey{}
+	}
+	return flightKey{number: number, date: x.registry.LocalDate(f.Start, f.Departure)}
+}
+
+// flightKeys returns the keys of every leg with a flight number.
+func (e entry) flightKeys() []flightKey {
+	keys := make([]flightKey, 0, len(e.keys))
+	for _, k := range e.keys {
+		if k != (flightKey{}) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// slotKeys returns the keys of every slot touched by a stay widened by
+// window on both sides.
+func (e entry) slotKeys(window time.Duration) []slotKey {
+	var keys []slotKey
+	for _, s := range e.stays {
+		for t := s.From.Add(-window).Truncate(slot); !t.After(s.To.Add(window)); t = t.Add(slot) {
+			keys = append(keys, slotKey{airport: s.Airport, slot: t.Unix()})
+		}
+	}
+	return keys
+}
+
+// link adds id to the bucket k of m.
+func link[K comparable](m map[K]map[string]struct{}, k K, id string) {
+	if m[k] == nil {
+		m[k] = make(map[string]struct{})
+	}
+	m[k][id] = struct{}{}
+}
+
+// unlink removes id from the bucket k of m, dropping the bucket once empty.
+func unlink[K comparable](m map[K]map[string]struct{}, k K, id string) {
+	delete(m[k], id)
+	if len(m[k]) == 0 {
+		delete(m, k)
+	}
+}
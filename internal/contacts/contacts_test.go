@@ -0,0 +1,144 @@
+package contacts
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// leg builds a timed segment departing dep hours and arriving arr hours after
+// midnight UTC on 2026-03-01.
+func leg(from, to, number string, dep, arr int) api.Flight {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	return api.Flight{
+		Start:     from,
+		End:       to,
+		Number:    number,
+		Departure: day.Add(time.Duration(dep) * time.Hour),
+		Arrival:   day.Add(time.Duration(arr) * time.Hour),
+	}
+}
+
+func TestTrace(t *testing.T) {
+	x := New(airports.Default())
+	// 1 flies SFO -> ATL on DL1, connects for 3h, then ATL -> EWR.
+	x.Set("1", []api.Flight{leg("ATL", "EWR", "DL2", 14, 16), leg("SFO", "ATL", "DL1", 6, 11)})
+	// 2 is on the same DL1, written differently.
+	x.Set("2", []api.Flight{leg("SFO", "ATL", "dl 1", 6, 11)})
+	// 3 passes through ATL during 1's connection.
+	x.Set("3", []api.Flight{leg("ORD", "ATL", "UA5", 9, 12), leg("ATL", "MIA", "UA6", 13, 15)})
+	// 4 leaves ATL an hour after 1 did.
+	x.Set("4", []api.Flight{leg("ATL", "BOS", "B61", 15, 17)})
+	// 5 leaves ATL four hours after 1 did: outside the default window.
+	x.Set("5", []api.Flight{leg("ATL", "BOS", "B63", 18, 20)})
+	// 10 flies DL1 the next day.
+	x.Set("10", []api.Flight{leg("SFO", "ATL", "DL1", 30, 35)})
+
+	tests := []struct {
+		window time.Duration
+		want   []string
+	}{
+		{window: DefaultWindow, want: []string{"2", "3", "4"}},
+		{window: 0, want: []string{"2", "3"}},
+		{window: 6 * time.Hour, want: []string{"2", "3", "4", "5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.window.String(), func(t *testing.T) {
+			got := x.Trace("1", tt.window)
+			ids := make([]string, 0, len(got))
+			for _, c := range got {
+				ids = append(ids, c.Passenger)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("Trace(1) contacts = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	got := x.Trace("1", DefaultWindow)
+	if c := got[0]; len(c.Flights) != 1 || c.Flights[0].Number != "dl 1" || len(c.Stays) != 2 {
+		t.Errorf("contact 2 = %+v, want the shared DL1 leg and the SFO and ATL stays", c)
+	}
+	if c := got[1]; len(c.Flights) != 0 || len(c.Stays) != 1 || c.Stays[0].Airport != "ATL" {
+		t.Errorf("contact 3 = %+v, want only the ATL connection", c)
+	}
+	if got := x.Trace("99", DefaultWindow); len(got) != 0 {
+		t.Errorf("Trace(unknown) = %+v, want no contacts", got)
+	}
+}
+
+func TestTraceFlightAcrossOffsets(t *testing.T) {
+	x := New(airports.Default())
+	// BA112 leaves JFK at 20:30 New York time on 1 March, 01:30 UTC on 2
+	// March. Both passengers report the same instant in different offsets.
+	utc := time.Date(2026, 3, 2, 1, 30, 0, 0, time.UTC)
+	local := utc.In(time.FixedZone("EST", -5*60*60))
+	x.Set("1", []api.Flight{{Start: "JFK", End: "LHR", Number: "BA112", Departure: utc, Arrival: utc.Add(7 * time.Hour)}})
+	x.Set("2", []api.Flight{{Start: "JFK", End: "LHR", Number: "BA 112", Departure: local, Arrival: local.Add(7 * time.Hour)}})
+	// 3 takes BA112 a day later.
+	x.Set("3", []api.Flight{{Start: "JFK", End: "LHR", Number: "BA112", Departure: utc.Add(24 * time.Hour)}})
+
+	got := x.Trace("1", 0)
+	if len(got) != 1 || got[0].Passenger != "2" || len(got[0].Flights) != 1 {
+		t.Errorf("Trace(1) = %+v, want passenger 2 on the shared BA112", got)
+	}
+}
+
+func TestSetReplaces(t *testing.T) {
+	x := New(airports.Default())
+	x.Set("1", []api.Flight{leg("SFO", "ATL", "DL1", 6, 11)})
+	x.Set("2", []api.Flight{leg("SFO", "ATL", "DL1", 6, 11)})
+	if got := x.Trace("1", 0); len(got) != 1 {
+		t.Fatalf("Trace(1) = %+v, want passenger 2", got)
+	}
+
+	// 2 continues to EWR: the old final stay at ATL at 11:00 is replaced by
+	// the 11:00-14:00 connection, and a shorter stale list is ignored.
+	x.Set("2", []api.Flight{leg("SFO", "ATL", "DL1", 6, 11), leg("ATL", "EWR", "DL2", 14, 16)})
+	x.Set("2", []api.Flight{leg("SFO", "ATL", "DL1", 6, 11)})
+	got := x.Trace("2", 0)
+	if len(got) != 1 || len(got[0].Stays) != 2 {
+		t.Fatalf("Trace(2) = %+v, want passenger 1 at SFO and ATL", got)
+	}
+	if len(x.passengers["2"].legs) != 2 {
+		t.Errorf("stale Set replaced the longer segment list")
+	}
+}
+
+func TestConnectionCap(t *testing.T) {
+	x := New(airports.Default())
+	// 1 stays in ATL for three days between legs; 2 is there in the middle.
+	x.Set("1", []api.Flight{leg("SFO", "ATL", "", 6, 11), leg("ATL", "EWR", "", 83, 85)})
+	x.Set("2", []api.Flight{leg("ORD", "ATL", "", 40, 42)})
+	if got := x.Trace("1", DefaultWindow); len(got) != 0 {
+		t.Errorf("Trace(1) = %+v, want no contact during a connection longer than a day", got)
+	}
+	if n := len(x.airports); n > 20 {
+		t.Errorf("airport index holds %d slots, want the long connection split into instants", n)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	s := store.NewMemory()
+	for range 2 {
+		p, err := s.CreatePassenger(api.Passenger{})
+		if err != nil {
+			t.Fatalf("CreatePassenger: %v", err)
+		}
+		accept := func([]api.Flight) error { return nil }
+		if _, err := s.AppendSegments(p.ID, []api.Flight{leg("SFO", "ATL", "DL1", 6, 11)}, accept); err != nil {
+			t.Fatalf("AppendSegments: %v", err)
+		}
+	}
+	x, err := Load(s, airports.Default())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := x.Trace("1", 0); len(got) != 1 || got[0].Passenger != "2" {
+		t.Errorf("Trace(1) after Load = %+v, want passenger 2", got)
+	}
+}
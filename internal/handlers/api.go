@@ -3,6 +3,8 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/AndriyKalashnykov/flight-path/pkg/api"
 )
@@ -16,48 +18,328 @@ var (
 
 	// ErrDisconnectedGraph is returned when more than one airport has no
 	// incoming edge or no outgoing edge — i.e., the input describes multiple
-	// distinct itineraries instead of a single connected path.
+	// distinct itineraries instead of a single connected path — or when some
+	// segments are not reachable from the start airport at all (a detached
+	// cycle beside an otherwise valid path, reported as *DisconnectedError).
 	ErrDisconnectedGraph = errors.New("disconnected graph: multiple distinct itineraries detected")
+
+	// ErrBranchingPath is returned when an airport has more than one distinct
+	// outgoing or incoming segment, so the input cannot be a single linear
+	// path. The concrete error is a *BranchingError naming the airport.
+	ErrBranchingPath = errors.New("branching path: an airport has more than one outgoing or incoming flight")
+
+	// ErrAnchorNotFound is returned when a circular itinerary is solved with
+	// an anchor airport that no segment departs from.
+	ErrAnchorNotFound = errors.New("anchor airport not found: no segment departs from the anchor")
 )
 
+// BranchingError reports the first airport found with more than one distinct
+// successor (Outgoing) or predecessor (!Outgoing), together with the indexes
+// of every segment leaving or entering it. It matches ErrBranchingPath via
+// errors.Is.
+type BranchingError struct {
+	Airport  string
+	Outgoing bool
+	Indexes  []int
+}
+
+func (e *BranchingError) Error() string {
+	direction := "incoming"
+	if e.Outgoing {
+		direction = "outgoing"
+	}
+	return fmt.Sprintf("branching path: airport %s has more than one %s flight (segments %v)",
+		e.Airport, direction, e.Indexes)
+}
+
+// Is reports whether target is ErrBranchingPath.
+func (e *BranchingError) Is(target error) bool {
+	return target == ErrBranchingPath
+}
+
+// CircularError reports input in which every airport is both a source and a
+// destination, so no start or end can be picked. Airports lists the airports
+// of every loop in travel order (each loop starting at its first-listed
+// source airport) and Indexes lists the segments involved — all of them. It
+// matches ErrCircularPath via errors.Is.
+type CircularError struct {
+	Airports []string
+	Indexes  []int
+}
+
+func (e *CircularError) Error() string {
+	return fmt.Sprintf("%v (airports %v)", ErrCircularPath, e.Airports)
+}
+
+// Is reports whether target is ErrCircularPath.
+func (e *CircularError) Is(target error) bool {
+	return target == ErrCircularPath
+}
+
+// DisconnectedError reports input that does not form one connected path.
+// StartCandidates and EndCandidates list (sorted) every airport with no
+// incoming and no outgoing segment respectively. Components lists each group
+// of airports that keeps the input from being a single path, in travel order
+// (a detached loop repeats its first airport at the end): every fragment when
+// there are several start candidates, or only the loops left off the path
+// when the path itself is unique. Indexes lists the segments of those
+// components. It matches ErrDisconnectedGraph via errors.Is.
+type DisconnectedError struct {
+	StartCandidates []string
+	EndCandidates   []string
+	Components      [][]string
+	Indexes         []int
+}
+
+func (e *DisconnectedError) Error() string {
+	if len(e.StartCandidates) > 1 || len(e.EndCandidates) > 1 {
+		return fmt.Sprintf("%v (start candidates %v, end candidates %v)",
+			ErrDisconnectedGraph, e.StartCandidates, e.EndCandidates)
+	}
+	return fmt.Sprintf("disconnected graph: %d segment(s) not on the itinerary path (components %v)",
+		len(e.Indexes), e.Components)
+}
+
+// Is reports whether target is ErrDisconnectedGraph.
+func (e *DisconnectedError) Is(target error) bool {
+	return target == ErrDisconnectedGraph
+}
+
 // FindItinerary determines the starting and ending airports for a single
 // connected itinerary represented by an unordered slice of flight segments.
-// It builds the in-degree and out-degree sets, identifies the unique source
-// (no incoming edge) and unique destination (no outgoing edge), and rejects
-// inputs that don't fit that shape via ErrCircularPath / ErrDisconnectedGraph.
+// It builds the successor and predecessor maps, rejecting any airport with
+// more than one distinct outgoing or incoming segment via ErrBranchingPath,
+// then identifies the unique source (no incoming edge) and unique destination
+// (no outgoing edge), and rejects inputs that don't fit that shape via
+// ErrCircularPath / ErrDisconnectedGraph. Finally it walks the path from the
+// start airport and confirms every segment lies on it, so a detached cycle
+// beside a valid path is reported as a *DisconnectedError instead of being
+// silently ignored. Duplicate segments of the same flight are tolerated and
+// behave as a single segment; see linkSegments.
 // Empty input returns ("", "", nil) — the caller is expected to reject empty
 // payloads before calling this function.
 // Time complexity: O(n), space complexity: O(n).
 func FindItinerary(flights []api.Flight) (start, end string, err error) {
+	return FindItineraryFrom(flights, "")
+}
+
+// FindItineraryFrom is FindItinerary with an optional anchor (home) airport
+// used to break round trips: when every airport is both a source and a
+// destination and anchor is non-empty, the loop is opened at anchor and
+// (anchor, anchor) is returned instead of ErrCircularPath. The anchor is only
+// consulted for circular input; linear paths are solved as usual.
+func FindItineraryFrom(flights []api.Flight, anchor string) (start, end string, err error) {
+	path, err := solvePath(flights, anchor)
+	if err != nil || len(path) == 0 {
+		return "", "", err
+	}
+	return path[0], path[len(path)-1], nil
+}
+
+// ReconstructItinerary returns the full ordered itinerary for a single
+// connected path: it locates the start airport the same way FindItinerary
+// does, then follows the successor map from there until the end airport is
+// reached, rejecting segments left off that path with a *DisconnectedError.
+// Duplicate segments of the same flight collapse into a single leg, matching
+// FindItinerary's set semantics; two different flights on one route are a
+// *BranchingError. Empty input returns an empty Itinerary and
+// a nil error.
+// Time complexity: O(n), space complexity: O(n).
+func ReconstructItinerary(flights []api.Flight) (api.Itinerary, error) {
+	return ReconstructItineraryFrom(flights, "")
+}
+
+// ReconstructItineraryFrom is ReconstructItinerary with an optional anchor
+// airport that breaks round trips, with the same semantics as
+// FindItineraryFrom: a circular itinerary is returned as the ordered loop
+// starting and ending at anchor. Each leg is the first input segment for
+// its route, timestamps included; when consecutive legs carry timestamps
+// that contradict the travel order a *ChronologyError is returned.
+func ReconstructItineraryFrom(flights []api.Flight, anchor string) (api.Itinerary, error) {
+	path, err := solvePath(flights, anchor)
+	if err != nil || len(path) == 0 {
+		return api.Itinerary{}, err
+	}
+	first := make(map[string]int, len(path))
+	for i := len(flights) - 1; i >= 0; i-- {
+		first[flights[i].Start] = i
+	}
+	order := make([]int, 0, len(path)-1)
+	for _, a := range path[:len(path)-1] {
+		order = append(order, first[a])
+	}
+	return legsItinerary(flights, order)
+}
+
+// solvePath links the segments, picks the start airport (the unique in-degree
+// 0 airport, or anchor for a closed loop) and walks the successor map,
+// returning the airports in travel order.
+func solvePath(flights []api.Flight, anchor string) ([]string, error) {
 	if len(flights) == 0 {
-		return "", "", nil
+		return nil, nil
 	}
 
-	starts := make(map[string]bool, len(flights))
-	ends := make(map[string]bool, len(flights))
-	for _, f := range flights {
-		starts[f.Start] = true
-		ends[f.End] = true
+	next, prev, err := linkSegments(flights)
+	if err != nil {
+		return nil, err
+	}
+	starts, ends := endpoints(next, prev)
+	var start string
+	switch {
+	case len(starts) == 0 && anchor == "":
+		return nil, newCircularError(flights, next)
+	case len(starts) == 0:
+		if _, ok := next[anchor]; !ok {
+			return nil, ErrAnchorNotFound
+		}
+		start = anchor
+	case len(starts) > 1 || len(ends) > 1:
+		return nil, newDisconnectedError(flights, next, nil, starts, ends)
+	default:
+		start = starts[0]
+	}
+
+	path := walk(next, start)
+	if len(path)-1 < len(next) {
+		return nil, newDisconnectedError(flights, next, path, starts, ends)
+	}
+	return path, nil
+}
+
+// walk follows the successor map from start and returns the airports visited
+// in order, stopping at an airport with no successor or when the walk closes
+// a loop back at start. Bounded by len(next): every step consumes a distinct
+// source airport, so the walk cannot loop forever even if the map is malformed.
+func walk(next map[string]string, start string) []string {
+	path := make([]string, 0, len(next)+1)
+	path = append(path, start)
+	for cur := start; len(path) <= len(next); {
+		dst, ok := next[cur]
+		if !ok {
+			break
+		}
+		path = append(path, dst)
+		if dst == start {
+			break
+		}
+		cur = dst
+	}
+	return path
+}
+
+// newCircularError lists the airports of every loop in travel order, walking
+// each loop from its first-listed source airport.
+func newCircularError(flights []api.Flight, next map[string]string) *CircularError {
+	ce := &CircularError{
+		Airports: make([]string, 0, len(next)),
+		Indexes:  make([]int, 0, len(flights)),
+	}
+	seen := make(map[string]bool, len(next))
+	for i, f := range flights {
+		ce.Indexes = append(ce.Indexes, i)
+		for cur := f.Start; !seen[cur]; cur = next[cur] {
+			seen[cur] = true
+			ce.Airports = append(ce.Airports, cur)
+		}
+	}
+	return ce
+}
+
+// newDisconnectedError builds the diagnostic for input that is not a single
+// path. path is the walk from the unique start airport, or nil when there
+// are several start candidates; in that case every fragment walked from a
+// start candidate becomes a component. Segments whose source airport is
+// neither on path nor on a fragment are grouped into loops by following the
+// successor map (with in- and out-degree ≤ 1, every such leftover is a loop).
+func newDisconnectedError(flights []api.Flight, next map[string]string, path, starts, ends []string) *DisconnectedError {
+	slices.Sort(starts)
+	slices.Sort(ends)
+	de := &DisconnectedError{StartCandidates: starts, EndCandidates: ends}
+
+	onPath := make(map[string]bool, len(path))
+	for _, a := range path {
+		onPath[a] = true
+	}
+	grouped := make(map[string]bool, len(next)+1)
+	if path == nil {
+		for _, s := range starts {
+			fragment := walk(next, s)
+			for _, a := range fragment {
+				grouped[a] = true
+			}
+			de.Components = append(de.Components, fragment)
+		}
+	}
+	for i, f := range flights {
+		if onPath[f.Start] {
+			continue
+		}
+		de.Indexes = append(de.Indexes, i)
+		if grouped[f.Start] {
+			continue
+		}
+		component := []string{f.Start}
+		grouped[f.Start] = true
+		for cur := next[f.Start]; !grouped[cur]; cur = next[cur] {
+			component = append(component, cur)
+			grouped[cur] = true
+		}
+		de.Components = append(de.Components, append(component, f.Start))
 	}
+	return de
+}
 
-	startCandidates := make([]string, 0, 1)
-	endCandidates := make([]string, 0, 1)
-	for s := range starts {
-		if !ends[s] {
-			startCandidates = append(startCandidates, s)
+// linkSegments builds the successor (next) and predecessor (prev) maps for
+// flights, enforcing in-degree ≤ 1 and out-degree ≤ 1 per airport. Duplicate
+// segments are tolerated when they describe the same flight, i.e. agree on
+// every flight number and time set by both or by an earlier duplicate; a
+// second distinct successor or
+// predecessor, or a second flight on the same route, yields a
+// *BranchingError for that airport.
+func linkSegments(flights []api.Flight) (next, prev map[string]string, err error) {
+	next = make(map[string]string, len(flights))
+	prev = make(map[string]string, len(flights))
+	known := make(map[string]api.Flight, len(flights))
+	for _, f := range flights {
+		if dst, ok := next[f.Start]; ok && (dst != f.End || conflicting(known[f.Start], f)) {
+			return nil, nil, newBranchingError(flights, f.Start, true)
 		}
+		known[f.Start] = fillFlight(f, known[f.Start])
+		if src, ok := prev[f.End]; ok && src != f.Start {
+			return nil, nil, newBranchingError(flights, f.End, false)
+		}
+		next[f.Start] = f.End
+		prev[f.End] = f.Start
 	}
-	for e := range ends {
-		if !starts[e] {
-			endCandidates = append(endCandidates, e)
+	return next, prev, nil
+}
+
+// newBranchingError collects the indexes of every segment leaving (outgoing)
+// or entering airport.
+func newBranchingError(flights []api.Flight, airport string, outgoing bool) *BranchingError {
+	indexes := make([]int, 0, 2)
+	for i, f := range flights {
+		if (outgoing && f.Start == airport) || (!outgoing && f.End == airport) {
+			indexes = append(indexes, i)
 		}
 	}
+	return &BranchingError{Airport: airport, Outgoing: outgoing, Indexes: indexes}
+}
 
-	if len(startCandidates) == 0 || len(endCandidates) == 0 {
-		return "", "", ErrCircularPath
+// endpoints lists the airports with no incoming edge (start candidates) and
+// the airports with no outgoing edge (end candidates).
+func endpoints(next, prev map[string]string) (starts, ends []string) {
+	starts = make([]string, 0, 1)
+	ends = make([]string, 0, 1)
+	for s := range next {
+		if _, ok := prev[s]; !ok {
+			starts = append(starts, s)
+		}
 	}
-	if len(startCandidates) > 1 || len(endCandidates) > 1 {
-		return "", "", ErrDisconnectedGraph
+	for e := range prev {
+		if _, ok := next[e]; !ok {
+			ends = append(ends, e)
+		}
 	}
-	return startCandidates[0], endCandidates[0], nil
+	return starts, ends
 }
@@ -53,3 +53,21 @@ func BenchmarkFindItinerary_500(b *testing.B) {
 		_, _, _ = FindItinerary(flights)
 	}
 }
+
+// Benchmarks the Eulerian solver on the very large dataset (500 flights)
+func BenchmarkFindEulerianItinerary_500(b *testing.B) {
+	flights := generateFlights(500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = FindEulerianItinerary(flights, "")
+	}
+}
+
+// Benchmarks full path reconstruction on the very large dataset (500 flights)
+func BenchmarkReconstructItinerary_500(b *testing.B) {
+	flights := generateFlights(500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ReconstructItinerary(flights)
+	}
+}
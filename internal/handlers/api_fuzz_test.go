@@ -13,8 +13,8 @@ import (
 	"github.com/AndriyKalashnykov/flight-path/pkg/api"
 )
 
-// FuzzFindItinerary tests that FindItinerary never panics on adversarial
-// 2-segment inputs. Kept as a tight inner-loop fuzz target — the broader
+// FuzzFindItinerary tests that FindItinerary, ReconstructItinerary and
+// FindEulerianItinerary never panic on adversarial 2-segment inputs. Kept as a tight inner-loop fuzz target — the broader
 // HTTP-layer fuzz lives in FuzzFlightCalculate below.
 func FuzzFindItinerary(f *testing.F) {
 	// Seed corpus from existing test cases.
@@ -29,6 +29,8 @@ func FuzzFindItinerary(f *testing.F) {
 			{Start: s2, End: d2},
 		}
 		_, _, _ = FindItinerary(flights)
+		_, _ = ReconstructItinerary(flights)
+		_, _ = FindEulerianItinerary(flights, s1)
 	})
 }
 
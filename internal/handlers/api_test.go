@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"slices"
 	"testing"
 
 	"github.com/AndriyKalashnykov/flight-path/pkg/api"
@@ -87,7 +88,7 @@ func TestFindItinerary(t *testing.T) {
 				{Start: "A", End: "B"},
 				{Start: "A", End: "C"},
 			},
-			wantErr: ErrDisconnectedGraph,
+			wantErr: ErrBranchingPath,
 		},
 		{
 			name: "two segments sharing a destination are rejected (ambiguous end)",
@@ -95,6 +96,25 @@ func TestFindItinerary(t *testing.T) {
 				{Start: "A", End: "C"},
 				{Start: "B", End: "C"},
 			},
+			wantErr: ErrBranchingPath,
+		},
+		{
+			name: "branching input with a unique start and end is rejected",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "A", End: "C"},
+				{Start: "B", End: "C"},
+			},
+			wantErr: ErrBranchingPath,
+		},
+		{
+			name: "detached cycle beside a valid path is rejected",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "C"},
+				{Start: "D", End: "E"},
+				{Start: "E", End: "D"},
+			},
 			wantErr: ErrDisconnectedGraph,
 		},
 		{
@@ -126,3 +146,313 @@ func TestFindItinerary(t *testing.T) {
 		})
 	}
 }
+
+func TestReconstructItinerary(t *testing.T) {
+	tests := []struct {
+		name     string
+		flights  []api.Flight
+		wantPath []string
+		wantErr  error
+	}{
+		{
+			name:     "empty input",
+			flights:  []api.Flight{},
+			wantPath: nil,
+		},
+		{
+			name: "single flight",
+			flights: []api.Flight{
+				{Start: "SFO", End: "EWR"},
+			},
+			wantPath: []string{"SFO", "EWR"},
+		},
+		{
+			name: "four flights shuffled",
+			flights: []api.Flight{
+				{Start: "IND", End: "EWR"},
+				{Start: "SFO", End: "ATL"},
+				{Start: "GSO", End: "IND"},
+				{Start: "ATL", End: "GSO"},
+			},
+			wantPath: []string{"SFO", "ATL", "GSO", "IND", "EWR"},
+		},
+		{
+			name:    "TestFlights fixture 19 segments BGY to AKL",
+			flights: api.TestFlights,
+			wantPath: []string{
+				"BGY", "RAR", "AUH", "FCO", "BCN", "PSC", "BLQ", "MAD", "SFO", "ATL",
+				"GSO", "IND", "EWR", "CHI", "JFK", "AAL", "HEL", "CAK", "BJZ", "AKL",
+			},
+		},
+		{
+			name: "duplicate segment collapses into a single leg",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "A", End: "B"},
+			},
+			wantPath: []string{"A", "B"},
+		},
+		{
+			name: "circular path is rejected",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "A"},
+			},
+			wantErr: ErrCircularPath,
+		},
+		{
+			name: "disconnected pairs are rejected",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "C", End: "D"},
+			},
+			wantErr: ErrDisconnectedGraph,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReconstructItinerary(tt.flights)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReconstructItinerary() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !slices.Equal(got.Path, tt.wantPath) {
+				t.Errorf("ReconstructItinerary() path = %v, want %v", got.Path, tt.wantPath)
+			}
+			if len(tt.wantPath) > 0 && len(got.Legs) != len(tt.wantPath)-1 {
+				t.Fatalf("ReconstructItinerary() legs = %d, want %d", len(got.Legs), len(tt.wantPath)-1)
+			}
+			for i, leg := range got.Legs {
+				if leg.Start != got.Path[i] || leg.End != got.Path[i+1] {
+					t.Errorf("leg %d = %s->%s, want %s->%s", i, leg.Start, leg.End, got.Path[i], got.Path[i+1])
+				}
+			}
+		})
+	}
+}
+
+func TestFindItineraryBranchingError(t *testing.T) {
+	tests := []struct {
+		name         string
+		flights      []api.Flight
+		wantAirport  string
+		wantOutgoing bool
+		wantIndexes  []int
+	}{
+		{
+			name: "two outgoing flights from A",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "A", End: "C"},
+				{Start: "B", End: "C"},
+			},
+			wantAirport:  "A",
+			wantOutgoing: true,
+			wantIndexes:  []int{0, 1},
+		},
+		{
+			name: "two incoming flights into D",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "D"},
+				{Start: "C", End: "E"},
+				{Start: "C", End: "E"},
+				{Start: "E", End: "D"},
+			},
+			wantAirport:  "D",
+			wantOutgoing: false,
+			wantIndexes:  []int{1, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FindItinerary(tt.flights)
+			var branching *BranchingError
+			if !errors.As(err, &branching) {
+				t.Fatalf("FindItinerary() err = %v, want *BranchingError", err)
+			}
+			if branching.Airport != tt.wantAirport {
+				t.Errorf("Airport = %q, want %q", branching.Airport, tt.wantAirport)
+			}
+			if branching.Outgoing != tt.wantOutgoing {
+				t.Errorf("Outgoing = %v, want %v", branching.Outgoing, tt.wantOutgoing)
+			}
+			if !slices.Equal(branching.Indexes, tt.wantIndexes) {
+				t.Errorf("Indexes = %v, want %v", branching.Indexes, tt.wantIndexes)
+			}
+		})
+	}
+}
+
+func TestFindItineraryDetachedCycles(t *testing.T) {
+	flights := []api.Flight{
+		{Start: "A", End: "B"},
+		{Start: "X", End: "Y"},
+		{Start: "D", End: "E"},
+		{Start: "B", End: "C"},
+		{Start: "E", End: "F"},
+		{Start: "Y", End: "X"},
+		{Start: "F", End: "D"},
+	}
+	_, _, err := FindItinerary(flights)
+	var disconnected *DisconnectedError
+	if !errors.As(err, &disconnected) {
+		t.Fatalf("FindItinerary() err = %v, want *DisconnectedError", err)
+	}
+	if !errors.Is(err, ErrDisconnectedGraph) {
+		t.Errorf("errors.Is(err, ErrDisconnectedGraph) = false, want true")
+	}
+	wantIndexes := []int{1, 2, 4, 5, 6}
+	if !slices.Equal(disconnected.Indexes, wantIndexes) {
+		t.Errorf("Indexes = %v, want %v", disconnected.Indexes, wantIndexes)
+	}
+	wantComponents := [][]string{{"X", "Y", "X"}, {"D", "E", "F", "D"}}
+	if !slices.EqualFunc(disconnected.Components, wantComponents, slices.Equal[[]string]) {
+		t.Errorf("Components = %v, want %v", disconnected.Components, wantComponents)
+	}
+}
+
+func TestReconstructItineraryFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		flights  []api.Flight
+		anchor   string
+		wantPath []string
+		wantErr  error
+	}{
+		{
+			name: "round trip opened at the anchor",
+			flights: []api.Flight{
+				{Start: "JFK", End: "SFO"},
+				{Start: "SFO", End: "JFK"},
+			},
+			anchor:   "SFO",
+			wantPath: []string{"SFO", "JFK", "SFO"},
+		},
+		{
+			name: "multi-stop loop opened at the anchor",
+			flights: []api.Flight{
+				{Start: "ATL", End: "EWR"},
+				{Start: "EWR", End: "SFO"},
+				{Start: "SFO", End: "ATL"},
+			},
+			anchor:   "EWR",
+			wantPath: []string{"EWR", "SFO", "ATL", "EWR"},
+		},
+		{
+			name: "round trip without an anchor is rejected",
+			flights: []api.Flight{
+				{Start: "JFK", End: "SFO"},
+				{Start: "SFO", End: "JFK"},
+			},
+			wantErr: ErrCircularPath,
+		},
+		{
+			name: "anchor outside the loop is rejected",
+			flights: []api.Flight{
+				{Start: "JFK", End: "SFO"},
+				{Start: "SFO", End: "JFK"},
+			},
+			anchor:  "ATL",
+			wantErr: ErrAnchorNotFound,
+		},
+		{
+			name: "two separate loops are rejected as disconnected",
+			flights: []api.Flight{
+				{Start: "JFK", End: "SFO"},
+				{Start: "SFO", End: "JFK"},
+				{Start: "ATL", End: "EWR"},
+				{Start: "EWR", End: "ATL"},
+			},
+			anchor:  "SFO",
+			wantErr: ErrDisconnectedGraph,
+		},
+		{
+			name: "anchor is ignored for a linear path",
+			flights: []api.Flight{
+				{Start: "ATL", End: "EWR"},
+				{Start: "SFO", End: "ATL"},
+			},
+			anchor:   "ATL",
+			wantPath: []string{"SFO", "ATL", "EWR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReconstructItineraryFrom(tt.flights, tt.anchor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReconstructItineraryFrom() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !slices.Equal(got.Path, tt.wantPath) {
+				t.Errorf("ReconstructItineraryFrom() path = %v, want %v", got.Path, tt.wantPath)
+			}
+			start, end, err := FindItineraryFrom(tt.flights, tt.anchor)
+			if err != nil {
+				t.Fatalf("FindItineraryFrom() err = %v", err)
+			}
+			if start != tt.wantPath[0] || end != tt.wantPath[len(tt.wantPath)-1] {
+				t.Errorf("FindItineraryFrom() = (%q, %q), want (%q, %q)", start, end, tt.wantPath[0], tt.wantPath[len(tt.wantPath)-1])
+			}
+		})
+	}
+}
+
+func TestFindItineraryCircularError(t *testing.T) {
+	flights := []api.Flight{
+		{Start: "B", End: "C"},
+		{Start: "X", End: "Y"},
+		{Start: "A", End: "B"},
+		{Start: "Y", End: "X"},
+		{Start: "C", End: "A"},
+	}
+	_, _, err := FindItinerary(flights)
+	var circular *CircularError
+	if !errors.As(err, &circular) {
+		t.Fatalf("FindItinerary() err = %v, want *CircularError", err)
+	}
+	if !errors.Is(err, ErrCircularPath) {
+		t.Errorf("errors.Is(err, ErrCircularPath) = false, want true")
+	}
+	if want := []string{"B", "C", "A", "X", "Y"}; !slices.Equal(circular.Airports, want) {
+		t.Errorf("Airports = %v, want %v", circular.Airports, want)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(circular.Indexes, want) {
+		t.Errorf("Indexes = %v, want %v", circular.Indexes, want)
+	}
+}
+
+func TestFindItineraryDisconnectedCandidates(t *testing.T) {
+	flights := []api.Flight{
+		{Start: "C", End: "D"},
+		{Start: "A", End: "B"},
+		{Start: "X", End: "Y"},
+		{Start: "Y", End: "X"},
+		{Start: "D", End: "E"},
+	}
+	_, _, err := FindItinerary(flights)
+	var disconnected *DisconnectedError
+	if !errors.As(err, &disconnected) {
+		t.Fatalf("FindItinerary() err = %v, want *DisconnectedError", err)
+	}
+	if want := []string{"A", "C"}; !slices.Equal(disconnected.StartCandidates, want) {
+		t.Errorf("StartCandidates = %v, want %v", disconnected.StartCandidates, want)
+	}
+	if want := []string{"B", "E"}; !slices.Equal(disconnected.EndCandidates, want) {
+		t.Errorf("EndCandidates = %v, want %v", disconnected.EndCandidates, want)
+	}
+	wantComponents := [][]string{{"A", "B"}, {"C", "D", "E"}, {"X", "Y", "X"}}
+	if !slices.EqualFunc(disconnected.Components, wantComponents, slices.Equal[[]string]) {
+		t.Errorf("Components = %v, want %v", disconnected.Components, wantComponents)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(disconnected.Indexes, want) {
+		t.Errorf("Indexes = %v, want %v", disconnected.Indexes, want)
+	}
+}
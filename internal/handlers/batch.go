@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// FlightBatch godoc
+// @Summary Solve many passengers' itineraries in one request.
+// @Description solve each passenger's segments concurrently on a bounded worker pool and return a result or an error per passenger ID, so one bad entry does not fail the batch. Each entry accepts the same shapes as POST /calculate; the anchor, mode and sources query parameters apply to every entry. With a callback URL the batch runs as an asynchronous job instead: the response is 202 with the job, and the finished job (its Batch field holding the results) is POSTed to the callback with an HMAC-SHA256 X-Signature-256 header.
+// @Tags FlightCalculate
+// @ID flightBatch-post
+// @Accept json
+// @Produce json
+// @Param   batch	body	map[string]api.CalculateRequest	true	"Passenger ID to flight segments (a CalculateRequest object or the legacy [][]string array)"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Param   callback	query	string	false	"public http(s) URL to POST the finished batch job to (requires WEBHOOK_SECRET; private hosts need WEBHOOK_ALLOWED_HOSTS); makes the request asynchronous"
+// @Success 200 {object} api.BatchResponse
+// @Success 202 {object} api.Job	"Queued as a job (callback given)"
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 503 {object} map[string]interface{}	"Job queue is full (callback given)"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/batch [post].
+func (h Handler) FlightBatch(c *echo.Context) error {
+	var entries map[string]json.RawMessage
+
+	// bind payload
+	err := c.Bind(&entries)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			errorKey: "Can't parse the payload",
+		})
+	}
+
+	// validate payload
+	if len(entries) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			errorKey: "Batch cannot be empty",
+		})
+	}
+
+	override := queryOptions(c)
+	if c.QueryParam(callbackParam) != "" {
+		return h.submitJob(c, func(ctx context.Context, job *api.Job) {
+			batch := h.solveBatch(ctx, entries, override)
+			job.Batch = &batch
+		})
+	}
+	return c.JSON(http.StatusOK, h.solveBatch(c.Request().Context(), entries, override))
+}
+
+// solveBatch decodes and solves every entry on at most h.batchWorkers
+// goroutines, layering override on each entry's own options. Decoding happens
+// inside the workers too, so a malformed entry only fails its own ID. Once
+// ctx is done the workers stop picking up entries and the remaining IDs
+// report the context error.
+func (h Handler) solveBatch(ctx context.Context, entries map[string]json.RawMessage, override api.Options) api.BatchResponse {
+	ids := slices.Sorted(maps.Keys(entries))
+	results := make([]api.BatchResult, len(ids))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(h.batchWorkers, len(ids)) {
+		wg.Go(func() {
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					results[i] = api.BatchResult{Error: err.Error()}
+					continue
+				}
+				results[i] = h.solveEntry(ctx, entries[ids[i]], override)
+			}
+		})
+	}
+	for i := range ids {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	resp := api.BatchResponse{Results: make(map[string]api.BatchResult, len(ids))}
+	for i, id := range ids {
+		resp.Results[id] = results[i]
+		if results[i].Error != "" {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	return resp
+}
+
+// solveEntry decodes and solves one batch entry.
+func (h Handler) solveEntry(ctx context.Context, raw json.RawMessage, override api.Options) api.BatchResult {
+	calc, errBody := h.decodeCalculation(raw, override)
+	if errBody != nil {
+		return batchFailure(errBody)
+	}
+	return solveResult(ctx, calc.flights, calc.options)
+}
+
+// solveResult solves flights with opts and reports the outcome as a
+// BatchResult. Once ctx is done it reports the context error instead of
+// starting the solve; a solve already under way runs to completion.
+func solveResult(ctx context.Context, flights []api.Flight, opts api.Options) api.BatchResult {
+	if err := ctx.Err(); err != nil {
+		return api.BatchResult{Error: err.Error()}
+	}
+	itinerary, err := solveItinerary(flights, opts.Mode, opts.Anchor)
+	if err != nil {
+		return batchFailure(itineraryErrorBody(err))
+	}
+	return api.BatchResult{
+		Start: itinerary.Path[0],
+		End:   itinerary.Path[len(itinerary.Path)-1],
+		Path:  itinerary.Path,
+	}
+}
+
+// batchFailure turns a 400 error body into a failed BatchResult, moving every
+// field except the message into Details.
+func batchFailure(body map[string]any) api.BatchResult {
+	result := api.BatchResult{}
+	result.Error, _ = body[errorKey].(string)
+	for k, v := range body {
+		if k == errorKey {
+			continue
+		}
+		if result.Details == nil {
+			result.Details = make(map[string]any, len(body)-1)
+		}
+		result.Details[k] = v
+	}
+	return result
+}
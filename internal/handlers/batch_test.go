@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestFlightBatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        string
+		body          string
+		wantStatus    int
+		wantSucceeded int
+		wantFailed    int
+		wantResults   map[string]api.BatchResult
+	}{
+		{
+			name: "one bad passenger does not fail the batch",
+			body: `{
+				"alice": [["ATL","EWR"],["SFO","ATL"]],
+				"bob": {"segments":[{"from":"jfk","to":"LHR"}]},
+				"carol": [["A","B"],["B","A"]],
+				"dave": [["SFO"]]
+			}`,
+			wantStatus:    http.StatusOK,
+			wantSucceeded: 2,
+			wantFailed:    2,
+			wantResults: map[string]api.BatchResult{
+				"alice": {Start: "SFO", End: "EWR", Path: []string{"SFO", "ATL", "EWR"}},
+				"bob":   {Start: "JFK", End: "LHR", Path: []string{"JFK", "LHR"}},
+				"carol": {Error: "circular path: every airport is both a source and a destination (airports [A B])"},
+				"dave":  {Error: "Each flight segment must contain both source and destination"},
+			},
+		},
+		{
+			name:          "query parameters apply to every entry",
+			target:        "/calculate/batch?anchor=A",
+			body:          `{"p1": [["A","B"],["B","A"]], "p2": [["B","A"],["A","B"]]}`,
+			wantStatus:    http.StatusOK,
+			wantSucceeded: 2,
+			wantResults: map[string]api.BatchResult{
+				"p1": {Start: "A", End: "A", Path: []string{"A", "B", "A"}},
+				"p2": {Start: "A", End: "A", Path: []string{"A", "B", "A"}},
+			},
+		},
+		{
+			name:       "empty batch returns 400",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "array body returns 400",
+			body:       `[["SFO","EWR"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/calculate/batch"
+			}
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			if err := New(WithBatchWorkers(2)).FlightBatch(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got api.BatchResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got.Succeeded != tt.wantSucceeded || got.Failed != tt.wantFailed {
+				t.Errorf("Succeeded/Failed = %d/%d, want %d/%d", got.Succeeded, got.Failed, tt.wantSucceeded, tt.wantFailed)
+			}
+			for id, want := range tt.wantResults {
+				r := got.Results[id]
+				if r.Start != want.Start || r.End != want.End || !slices.Equal(r.Path, want.Path) || r.Error != want.Error {
+					t.Errorf("Results[%s] = %+v, want %+v", id, r, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveBatchDetailsAndCancellation(t *testing.T) {
+	entries := make(map[string]json.RawMessage, 50)
+	for i := range 50 {
+		entries[fmt.Sprintf("p%02d", i)] = json.RawMessage(`[["SFO","ATL"],["ATL","SFO"],["SFO","SFO"]]`)
+	}
+	h := New(WithBatchWorkers(4))
+
+	got := h.solveBatch(context.Background(), entries, api.Options{})
+	if got.Failed != 50 {
+		t.Fatalf("Failed = %d, want 50", got.Failed)
+	}
+	if idx := got.Results["p07"].Details[indexKey]; idx != 2 {
+		t.Errorf("Details[Index] = %v, want 2", idx)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got = h.solveBatch(ctx, entries, api.Options{})
+	if got.Failed != 50 || got.Results["p00"].Error != context.Canceled.Error() {
+		t.Errorf("canceled batch = %d failed, p00 = %+v", got.Failed, got.Results["p00"])
+	}
+	if r := solveResult(ctx, []api.Flight{{Start: "SFO", End: "ATL"}}, api.Options{}); r.Error != context.Canceled.Error() || r.Path != nil {
+		t.Errorf("canceled solve = %+v, want the context error and no path", r)
+	}
+}
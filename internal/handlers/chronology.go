@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// ErrChronology is returned when segment timestamps contradict the itinerary.
+// The concrete error is a *ChronologyError naming the two legs in conflict.
+var ErrChronology = errors.New("chronology conflict: segment timestamps contradict the itinerary")
+
+// ChronologyError reports two consecutive legs that cannot be flown in that
+// order: Next departs before Previous arrives, or — when the order comes from
+// the timestamps — Next does not leave from the airport where Previous
+// landed. Indexes holds the payload indexes of Previous and Next. It matches
+// ErrChronology via errors.Is.
+type ChronologyError struct {
+	Previous api.Flight
+	Next     api.Flight
+	Indexes  []int
+}
+
+func (e *ChronologyError) Error() string {
+	if e.Previous.End != e.Next.Start {
+		return fmt.Sprintf("chronology conflict: segment %d (%s->%s) is the next departure after segment %d (%s->%s) but does not leave from %s",
+			e.Indexes[1], e.Next.Start, e.Next.End, e.Indexes[0], e.Previous.Start, e.Previous.End, e.Previous.End)
+	}
+	return fmt.Sprintf("chronology conflict: segment %d (%s->%s) departs at %s, before segment %d (%s->%s) arrives at %s",
+		e.Indexes[1], e.Next.Start, e.Next.End, e.Next.Departure.Format(time.RFC3339),
+		e.Indexes[0], e.Previous.Start, e.Previous.End, e.Previous.Arrival.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrChronology.
+func (e *ChronologyError) Is(target error) bool {
+	return target == ErrChronology
+}
+
+// ReconstructTimedItinerary orders segments by departure time instead of by
+// graph shape, so round trips and revisited airports need no anchor or
+// special mode. Every segment must carry a Departure; each leg must leave
+// from the airport where the previous one landed, and not before it landed
+// (when the previous Arrival is known). Violations are reported as a
+// *ChronologyError. Segments with equal departures keep their input order.
+// Empty input returns an empty Itinerary and a nil error.
+// Time complexity: O(n log n), space complexity: O(n).
+func ReconstructTimedItinerary(flights []api.Flight) (api.Itinerary, error) {
+	order := make([]int, len(flights))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return flights[a].Departure.Compare(flights[b].Departure)
+	})
+	return legsItinerary(flights, order)
+}
+
+// allTimed reports whether every segment carries a departure time.
+func allTimed(flights []api.Flight) bool {
+	for _, f := range flights {
+		if f.Departure.IsZero() {
+			return false
+		}
+	}
+	return len(flights) > 0
+}
+
+// legsItinerary builds the itinerary that flies flights[order[0]],
+// flights[order[1]], ... and validates every connection with checkConnection.
+func legsItinerary(flights []api.Flight, order []int) (api.Itinerary, error) {
+	if len(order) == 0 {
+		return api.Itinerary{}, nil
+	}
+	path := make([]string, 0, len(order)+1)
+	legs := make([]api.Flight, 0, len(order))
+	path = append(path, flights[order[0]].Start)
+	for k, i := range order {
+		if k > 0 {
+			if err := checkConnection(flights, order[k-1], i); err != nil {
+				return api.Itinerary{}, err
+			}
+		}
+		legs = append(legs, flights[i])
+		path = append(path, flights[i].End)
+	}
+	return api.Itinerary{Path: path, Legs: legs}, nil
+}
+
+// checkConnection verifies that flights[next] can follow flights[prev]: it
+// must leave from the airport where prev landed, and — when both timestamps
+// are known — not before prev arrived.
+func checkConnection(flights []api.Flight, prev, next int) error {
+	p, n := flights[prev], flights[next]
+	landed := p.Arrival
+	if landed.IsZero() {
+		landed = p.Departure
+	}
+	if p.End != n.Start || (!landed.IsZero() && !n.Departure.IsZero() && n.Departure.Before(landed)) {
+		return &ChronologyError{Previous: p, Next: n, Indexes: []int{prev, next}}
+	}
+	return nil
+}
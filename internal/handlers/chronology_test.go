@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// at parses an RFC 3339 timestamp, failing the test on error.
+func at(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return ts
+}
+
+func TestReconstructTimedItinerary(t *testing.T) {
+	tests := []struct {
+		name        string
+		flights     []api.Flight
+		wantPath    []string
+		wantIndexes []int
+		wantErr     error
+	}{
+		{
+			name: "shuffled segments follow departure order",
+			flights: []api.Flight{
+				{Start: "ATL", End: "EWR", Departure: at(t, "2026-03-01T13:00:00Z"), Arrival: at(t, "2026-03-01T15:00:00Z")},
+				{Start: "SFO", End: "ATL", Departure: at(t, "2026-03-01T06:00:00Z"), Arrival: at(t, "2026-03-01T11:00:00Z")},
+			},
+			wantPath: []string{"SFO", "ATL", "EWR"},
+		},
+		{
+			name: "round trip revisiting an airport",
+			flights: []api.Flight{
+				{Start: "SFO", End: "EWR", Departure: at(t, "2026-03-01T08:00:00Z")},
+				{Start: "ATL", End: "SFO", Departure: at(t, "2026-03-09T08:00:00Z")},
+				{Start: "EWR", End: "ATL", Departure: at(t, "2026-03-04T08:00:00Z")},
+			},
+			wantPath: []string{"SFO", "EWR", "ATL", "SFO"},
+		},
+		{
+			name: "next departure leaves from another airport",
+			flights: []api.Flight{
+				{Start: "SFO", End: "ATL", Departure: at(t, "2026-03-01T06:00:00Z")},
+				{Start: "GSO", End: "IND", Departure: at(t, "2026-03-01T09:00:00Z")},
+				{Start: "ATL", End: "GSO", Departure: at(t, "2026-03-01T12:00:00Z")},
+			},
+			wantErr:     ErrChronology,
+			wantIndexes: []int{0, 1},
+		},
+		{
+			name: "connection departs before the previous leg lands",
+			flights: []api.Flight{
+				{Start: "SFO", End: "ATL", Departure: at(t, "2026-03-01T06:00:00Z"), Arrival: at(t, "2026-03-01T11:00:00Z")},
+				{Start: "ATL", End: "EWR", Departure: at(t, "2026-03-01T10:00:00Z")},
+			},
+			wantErr:     ErrChronology,
+			wantIndexes: []int{0, 1},
+		},
+		{
+			name:    "empty input",
+			flights: []api.Flight{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReconstructTimedItinerary(tt.flights)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var ce *ChronologyError
+				if !errors.As(err, &ce) {
+					t.Fatalf("error %T is not a *ChronologyError", err)
+				}
+				if !slices.Equal(ce.Indexes, tt.wantIndexes) {
+					t.Errorf("Indexes = %v, want %v", ce.Indexes, tt.wantIndexes)
+				}
+				return
+			}
+			if !slices.Equal(got.Path, tt.wantPath) {
+				t.Errorf("path = %v, want %v", got.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestReconstructItineraryChronology(t *testing.T) {
+	// Graph order is SFO->ATL->EWR; only the second leg is timed, so the
+	// graph solver orders the legs and the timestamps are only checked.
+	flights := []api.Flight{
+		{Start: "ATL", End: "EWR", Departure: at(t, "2026-03-01T09:00:00Z")},
+		{Start: "SFO", End: "ATL"},
+	}
+	got, err := ReconstructItinerary(flights)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Legs[1].Departure.Equal(flights[0].Departure) {
+		t.Errorf("leg timestamps not carried: %+v", got.Legs)
+	}
+
+	// Timing both legs so that the connection leaves before landing.
+	flights[1].Departure = at(t, "2026-03-01T06:00:00Z")
+	flights[1].Arrival = at(t, "2026-03-01T11:00:00Z")
+	_, err = ReconstructItinerary(flights)
+	var ce *ChronologyError
+	if !errors.As(err, &ce) {
+		t.Fatalf("error = %v, want *ChronologyError", err)
+	}
+	if !slices.Equal(ce.Indexes, []int{1, 0}) {
+		t.Errorf("Indexes = %v, want [1 0]", ce.Indexes)
+	}
+}
+
+func TestReconstructItinerarySameRouteTwice(t *testing.T) {
+	t0 := at(t, "2026-03-01T06:00:00Z")
+	// A timed duplicate and an untimed one describe the flight already
+	// there and collapse into it.
+	got, err := ReconstructItinerary([]api.Flight{
+		{Start: "A", End: "B", Number: "XY1", Departure: t0},
+		{Start: "B", End: "C"},
+		{Start: "A", End: "B", Number: "xy 1"},
+		{Start: "A", End: "B", Departure: t0},
+	})
+	if err != nil || !slices.Equal(got.Path, []string{"A", "B", "C"}) {
+		t.Fatalf("same flight twice = %+v, %v, want [A B C]", got, err)
+	}
+
+	// A second departure, arrival or flight number on the route is another
+	// flight, which a single path cannot contain.
+	for _, other := range []api.Flight{
+		{Start: "A", End: "B", Departure: t0.Add(48 * time.Hour)},
+		{Start: "A", End: "B", Arrival: t0},
+		{Start: "A", End: "B", Number: "XY2"},
+	} {
+		flights := []api.Flight{
+			{Start: "A", End: "B", Number: "XY1", Departure: t0, Arrival: t0.Add(time.Hour)},
+			{Start: "B", End: "C"},
+			other,
+		}
+		_, err := ReconstructItinerary(flights)
+		var branching *BranchingError
+		if !errors.As(err, &branching) || branching.Airport != "A" || !branching.Outgoing || !slices.Equal(branching.Indexes, []int{0, 2}) {
+			t.Errorf("ReconstructItinerary(+%+v) error = %v, want an outgoing branch at A over [0 2]", other, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// SplitItineraries partitions flights into connected components — groups of
+// segments linked by a shared airport, ignoring direction — and solves each
+// group on its own with solve (e.g. ReconstructItinerary). Components are
+// returned in order of their first segment's index, so a payload holding
+// several separate trips yields one entry per trip instead of failing with
+// ErrDisconnectedGraph. A component that solve rejects keeps its Indexes and
+// carries the error message instead of a path.
+// Time complexity: O(n·α(n)) for the partition plus the cost of solve on
+// each component; space complexity: O(n).
+func SplitItineraries(flights []api.Flight, solve func([]api.Flight) (api.Itinerary, error)) []api.Component {
+	if len(flights) == 0 {
+		return nil
+	}
+
+	uf := newUnionFind(len(flights))
+	for _, f := range flights {
+		uf.union(f.Start, f.End)
+	}
+
+	order := make([]string, 0, 1)
+	groups := make(map[string][]int, 1)
+	for i, f := range flights {
+		root := uf.find(f.Start)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	components := make([]api.Component, 0, len(order))
+	for _, root := range order {
+		indexes := groups[root]
+		segments := make([]api.Flight, 0, len(indexes))
+		for _, i := range indexes {
+			segments = append(segments, flights[i])
+		}
+		comp := api.Component{Indexes: indexes}
+		itinerary, err := solve(segments)
+		if err != nil {
+			comp.Error = err.Error()
+		} else {
+			comp.Start = itinerary.Path[0]
+			comp.End = itinerary.Path[len(itinerary.Path)-1]
+			comp.Path = itinerary.Path
+		}
+		components = append(components, comp)
+	}
+	return components
+}
+
+// unionFind is a disjoint-set forest over airport codes with path halving
+// and union by size.
+type unionFind struct {
+	parent map[string]string
+	size   map[string]int
+}
+
+func newUnionFind(capacity int) *unionFind {
+	return &unionFind{
+		parent: make(map[string]string, capacity+1),
+		size:   make(map[string]int, capacity+1),
+	}
+}
+
+// find returns the representative of a's set, adding a as a singleton set
+// if it has not been seen before.
+func (u *unionFind) find(a string) string {
+	if _, ok := u.parent[a]; !ok {
+		u.parent[a] = a
+		u.size[a] = 1
+		return a
+	}
+	for u.parent[a] != a {
+		u.parent[a] = u.parent[u.parent[a]]
+		a = u.parent[a]
+	}
+	return a
+}
+
+// union merges the sets containing a and b and reports whether they were
+// previously separate.
+func (u *unionFind) union(a, b string) bool {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return false
+	}
+	if u.size[ra] < u.size[rb] {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+	u.size[ra] += u.size[rb]
+	return true
+}
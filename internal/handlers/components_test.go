@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestSplitItineraries(t *testing.T) {
+	tests := []struct {
+		name    string
+		flights []api.Flight
+		want    []api.Component
+	}{
+		{
+			name:    "empty input",
+			flights: []api.Flight{},
+			want:    nil,
+		},
+		{
+			name: "single trip is one component",
+			flights: []api.Flight{
+				{Start: "ATL", End: "EWR"},
+				{Start: "SFO", End: "ATL"},
+			},
+			want: []api.Component{
+				{Start: "SFO", End: "EWR", Path: []string{"SFO", "ATL", "EWR"}, Indexes: []int{0, 1}},
+			},
+		},
+		{
+			name: "two trips interleaved",
+			flights: []api.Flight{
+				{Start: "LHR", End: "CDG"},
+				{Start: "SFO", End: "ATL"},
+				{Start: "CDG", End: "FCO"},
+				{Start: "ATL", End: "EWR"},
+			},
+			want: []api.Component{
+				{Start: "LHR", End: "FCO", Path: []string{"LHR", "CDG", "FCO"}, Indexes: []int{0, 2}},
+				{Start: "SFO", End: "EWR", Path: []string{"SFO", "ATL", "EWR"}, Indexes: []int{1, 3}},
+			},
+		},
+		{
+			name: "invalid trip keeps its indexes and error",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "C", End: "D"},
+				{Start: "D", End: "C"},
+			},
+			want: []api.Component{
+				{Start: "A", End: "B", Path: []string{"A", "B"}, Indexes: []int{0}},
+				{Indexes: []int{1, 2}, Error: (&CircularError{Airports: []string{"C", "D"}, Indexes: []int{0, 1}}).Error()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitItineraries(tt.flights, ReconstructItinerary)
+			if len(got) != len(tt.want) {
+				t.Fatalf("SplitItineraries() = %d components, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				g, w := got[i], tt.want[i]
+				if g.Start != w.Start || g.End != w.End || g.Error != w.Error ||
+					!slices.Equal(g.Path, w.Path) || !slices.Equal(g.Indexes, w.Indexes) {
+					t.Errorf("component %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestFlightComponents(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantStarts []string
+	}{
+		{
+			name:       "two trips",
+			target:     "/calculate/components",
+			body:       `[["LHR","CDG"],["SFO","ATL"],["CDG","FCO"],["ATL","EWR"]]`,
+			wantStatus: http.StatusOK,
+			wantStarts: []string{"LHR", "SFO"},
+		},
+		{
+			name:       "round trips sharing a home airport in eulerian mode",
+			target:     "/calculate/components?mode=eulerian&anchor=SFO",
+			body:       `[["SFO","JFK"],["JFK","SFO"],["SFO","LAX"],["LAX","SFO"],["LHR","CDG"]]`,
+			wantStatus: http.StatusOK,
+			wantStarts: []string{"SFO", "LHR"},
+		},
+		{
+			name:       "unknown mode returns 400",
+			target:     "/calculate/components?mode=fastest",
+			body:       `[["SFO","EWR"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "empty array returns 400",
+			target:     "/calculate/components",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := New()
+			if err := h.FlightComponents(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got []api.Component
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			starts := make([]string, 0, len(got))
+			for _, comp := range got {
+				if comp.Error != "" {
+					t.Errorf("component %v unexpectedly failed: %s", comp.Indexes, comp.Error)
+				}
+				starts = append(starts, comp.Start)
+			}
+			if !slices.Equal(starts, tt.wantStarts) {
+				t.Errorf("starts = %v, want %v", starts, tt.wantStarts)
+			}
+		})
+	}
+}
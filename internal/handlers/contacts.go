@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/contacts"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// windowParam is the query parameter bounding the gap between two airport
+// stays that still counts as shared.
+const windowParam = "window"
+
+// PassengerContacts godoc
+// @Summary Trace a passenger's contacts.
+// @Description list every other passenger who flew the same flight number on the same date as this one, or was at the same airport within the window of them, with the shared legs and airport stays. Answered from an index keyed by (flight number, date) and (airport, hour).
+// @Tags Passengers
+// @ID passengerContacts-get
+// @Produce json
+// @Param   id	path	string	true	"Passenger ID"
+// @Param   window	query	string	false	"Largest gap between two airport stays that still links them, as a Go duration up to 24h (default 2h)"
+// @Success 200 {object} api.ContactTrace
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 404 {object} map[string]interface{}	"Passenger not found"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /passengers/{id}/contacts [get].
+func (h Handler) PassengerContacts(c *echo.Context) error {
+	window := contacts.DefaultWindow
+	if raw := c.QueryParam(windowParam); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 || d > contacts.MaxWindow {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				errorKey: "window must be a duration between 0s and " + contacts.MaxWindow.String(),
+			})
+		}
+		window = d
+	}
+
+	p, err := h.itineraries.Passenger(c.Param("id"))
+	if err != nil {
+		return passengerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, api.ContactTrace{
+		Passenger: p.ID,
+		Window:    window.String(),
+		Contacts:  h.contacts.Trace(p.ID, window),
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestPassengerContacts(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+	for _, segments := range []string{
+		`{"segments":[{"from":"SFO","to":"ATL","flight":"DL1","departs":"2026-03-01T06:00:00Z","arrives":"2026-03-01T11:00:00Z"}]}`,
+		`{"segments":[{"from":"SFO","to":"ATL","flight":"DL1","departs":"2026-03-01T06:00:00Z","arrives":"2026-03-01T11:00:00Z"}]}`,
+		`[["ATL","BOS","2026-03-01T15:00:00Z","2026-03-01T17:00:00Z"]]`,
+	} {
+		p, err := h.itineraries.CreatePassenger(api.Passenger{})
+		if err != nil {
+			t.Fatalf("CreatePassenger: %v", err)
+		}
+		rec := serveRequest(t, h.PassengerAppend, http.MethodPost, "/passengers/"+p.ID+"/segments", p.ID, segments)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("append status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	tests := []struct {
+		target       string
+		wantStatus   int
+		wantContacts []string
+	}{
+		{target: "/passengers/1/contacts", wantStatus: http.StatusOK, wantContacts: []string{"2"}},
+		{target: "/passengers/1/contacts?window=4h", wantStatus: http.StatusOK, wantContacts: []string{"2", "3"}},
+		{target: "/passengers/1/contacts?window=48h", wantStatus: http.StatusBadRequest},
+		{target: "/passengers/1/contacts?window=soon", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := serveRequest(t, h.PassengerContacts, http.MethodGet, tt.target, "1", "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var trace api.ContactTrace
+			if err := json.Unmarshal(rec.Body.Bytes(), &trace); err != nil {
+				t.Fatalf("failed to unmarshal trace: %v", err)
+			}
+			if len(trace.Contacts) != len(tt.wantContacts) {
+				t.Fatalf("contacts = %+v, want %v", trace.Contacts, tt.wantContacts)
+			}
+			for i, c := range trace.Contacts {
+				if c.Passenger != tt.wantContacts[i] {
+					t.Errorf("contact %d = %s, want %s", i, c.Passenger, tt.wantContacts[i])
+				}
+			}
+			if first := trace.Contacts[0]; len(first.Flights) != 1 || first.Flights[0].Number != "DL1" {
+				t.Errorf("contact 2 flights = %+v, want the shared DL1 leg", first.Flights)
+			}
+		})
+	}
+
+	if rec := serveRequest(t, h.PassengerContacts, http.MethodGet, "/passengers/9/contacts", "9", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown passenger status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// TraceCountries maps every airport of itinerary to its country using reg and
+// reports the countries in travel order, the international border crossings
+// and whether the trip is domestic-only. An airport missing from reg yields
+// an *UnknownAirportError.
+// Time complexity: O(n), space complexity: O(n).
+func TraceCountries(itinerary api.Itinerary, reg *airports.Registry) (api.CountryReport, error) {
+	if len(itinerary.Path) == 0 {
+		return api.CountryReport{}, nil
+	}
+
+	countries := make([]string, 0, len(itinerary.Path))
+	for _, code := range itinerary.Path {
+		a, ok := reg.Lookup(code)
+		if !ok {
+			return api.CountryReport{}, &UnknownAirportError{Airport: code}
+		}
+		countries = append(countries, a.Country)
+	}
+
+	report := api.CountryReport{
+		Countries: []string{countries[0]},
+		Crossings: make([]api.BorderCrossing, 0),
+	}
+	for i := 1; i < len(countries); i++ {
+		if countries[i] == countries[i-1] {
+			continue
+		}
+		report.Countries = append(report.Countries, countries[i])
+		report.Crossings = append(report.Crossings, api.BorderCrossing{
+			Start: itinerary.Path[i-1],
+			End:   itinerary.Path[i],
+			From:  countries[i-1],
+			To:    countries[i],
+		})
+	}
+	report.Domestic = len(report.Crossings) == 0
+	return report, nil
+}
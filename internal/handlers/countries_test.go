@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestTraceCountries(t *testing.T) {
+	reg := airports.Default()
+	tests := []struct {
+		name          string
+		flights       []api.Flight
+		anchor        string
+		wantCountries []string
+		wantCrossings []api.BorderCrossing
+		wantDomestic  bool
+		wantErr       error
+	}{
+		{
+			name:          "domestic trip",
+			flights:       []api.Flight{{Start: "ATL", End: "EWR"}, {Start: "SFO", End: "ATL"}},
+			wantCountries: []string{"US"},
+			wantCrossings: []api.BorderCrossing{},
+			wantDomestic:  true,
+		},
+		{
+			name: "international connection",
+			flights: []api.Flight{
+				{Start: "LHR", End: "FCO"},
+				{Start: "JFK", End: "LHR"},
+				{Start: "SFO", End: "JFK"},
+			},
+			wantCountries: []string{"US", "GB", "IT"},
+			wantCrossings: []api.BorderCrossing{
+				{Start: "JFK", End: "LHR", From: "US", To: "GB"},
+				{Start: "LHR", End: "FCO", From: "GB", To: "IT"},
+			},
+		},
+		{
+			name:          "round trip re-enters the home country",
+			flights:       []api.Flight{{Start: "JFK", End: "CDG"}, {Start: "CDG", End: "JFK"}},
+			anchor:        "JFK",
+			wantCountries: []string{"US", "FR", "US"},
+			wantCrossings: []api.BorderCrossing{
+				{Start: "JFK", End: "CDG", From: "US", To: "FR"},
+				{Start: "CDG", End: "JFK", From: "FR", To: "US"},
+			},
+		},
+		{
+			name:    "unknown airport",
+			flights: []api.Flight{{Start: "JFK", End: "XXX"}},
+			wantErr: ErrUnknownAirport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itinerary, err := ReconstructItineraryFrom(tt.flights, tt.anchor)
+			if err != nil {
+				t.Fatalf("ReconstructItineraryFrom: %v", err)
+			}
+			got, err := TraceCountries(itinerary, reg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !slices.Equal(got.Countries, tt.wantCountries) {
+				t.Errorf("Countries = %v, want %v", got.Countries, tt.wantCountries)
+			}
+			if !slices.Equal(got.Crossings, tt.wantCrossings) {
+				t.Errorf("Crossings = %v, want %v", got.Crossings, tt.wantCrossings)
+			}
+			if got.Domestic != tt.wantDomestic {
+				t.Errorf("Domestic = %v, want %v", got.Domestic, tt.wantDomestic)
+			}
+		})
+	}
+}
+
+func TestFlightCountries(t *testing.T) {
+	body := `[["LHR","FCO"],["JFK","LHR"]]`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate/countries", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	if err := New().FlightCountries(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var got api.CountryReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !slices.Equal(got.Countries, []string{"US", "GB", "IT"}) || len(got.Crossings) != 2 || got.Domestic {
+		t.Errorf("report = %+v", got)
+	}
+}
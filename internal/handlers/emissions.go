@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// Cabin classes accepted by EstimateEmissions. An empty cabin means economy.
+const (
+	cabinEconomy        = "economy"
+	cabinPremiumEconomy = "premium_economy"
+	cabinBusiness       = "business"
+	cabinFirst          = "first"
+)
+
+// errUnknownCabin is returned for a cabin class not in cabinMultipliers.
+var errUnknownCabin = errors.New("unknown cabin")
+
+// Distance-band method. Each leg's great-circle distance is increased by
+// distanceUplift to account for routing and holding, then multiplied by the
+// economy emission factor (kg CO2 per passenger-km) of its haul band and by
+// the cabin multiplier, which reflects the floor space a seat occupies. The
+// uplift and multipliers follow the DEFRA/DESNZ conversion-factor methodology;
+// see specs/ALGORITHM.md for the sources of every constant.
+const (
+	distanceUplift = 1.08
+
+	shortHaulMaxKm  = 1500
+	mediumHaulMaxKm = 4000
+
+	shortHaulKgPerKm  = 0.156
+	mediumHaulKgPerKm = 0.131
+	longHaulKgPerKm   = 0.115
+)
+
+// cabinMultipliers scales the economy factor by cabin class.
+var cabinMultipliers = map[string]float64{
+	cabinEconomy:        1.0,
+	cabinPremiumEconomy: 1.6,
+	cabinBusiness:       2.9,
+	cabinFirst:          4.0,
+}
+
+// EstimateEmissions estimates the per-passenger CO2 of every leg of itinerary
+// and their total, using the distance-band method above with the coordinates
+// in reg. cabin is matched case-insensitively and defaults to economy; an
+// unknown cabin is rejected, and an airport missing from reg yields an
+// *UnknownAirportError. Values are rounded to 0.1 kg.
+// Time complexity: O(n), space complexity: O(n).
+func EstimateEmissions(itinerary api.Itinerary, reg *airports.Registry, cabin string) (api.Emissions, error) {
+	cabin = strings.ToLower(cabin)
+	if cabin == "" {
+		cabin = cabinEconomy
+	}
+	multiplier, ok := cabinMultipliers[cabin]
+	if !ok {
+		return api.Emissions{}, fmt.Errorf("%w %q: want %q, %q, %q or %q",
+			errUnknownCabin, cabin, cabinEconomy, cabinPremiumEconomy, cabinBusiness, cabinFirst)
+	}
+
+	emissions := api.Emissions{Cabin: cabin, Legs: make([]api.LegEmissions, 0, len(itinerary.Legs))}
+	var total float64
+	for _, leg := range itinerary.Legs {
+		km, err := legKm(reg, leg)
+		if err != nil {
+			return api.Emissions{}, err
+		}
+		band, factor := haulBand(km)
+		kg := km * distanceUplift * factor * multiplier
+		total += kg
+		emissions.Legs = append(emissions.Legs, api.LegEmissions{
+			Start: leg.Start,
+			End:   leg.End,
+			Km:    math.Round(km*10) / 10,
+			Band:  band,
+			KgCO2: math.Round(kg*10) / 10,
+		})
+	}
+	emissions.TotalKgCO2 = math.Round(total*10) / 10
+	return emissions, nil
+}
+
+// haulBand returns the band name and economy emission factor for a leg of km
+// kilometres.
+func haulBand(km float64) (string, float64) {
+	switch {
+	case km < shortHaulMaxKm:
+		return "short", shortHaulKgPerKm
+	case km < mediumHaulMaxKm:
+		return "medium", mediumHaulKgPerKm
+	default:
+		return "long", longHaulKgPerKm
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestEstimateEmissions(t *testing.T) {
+	reg := airports.Default()
+	// Expected figures are worked out by hand from the published method:
+	// great-circle km x 1.08 uplift x band factor x cabin multiplier, e.g.
+	// JFK -> LHR is 5540.2 km x 1.08 x 0.115 = 688.1 kg in economy and
+	// x 2.9 = 1995.5 kg in business.
+	tests := []struct {
+		name      string
+		flights   []api.Flight
+		cabin     string
+		wantCabin string
+		wantBands []string
+		wantKg    []float64
+		wantTotal float64
+		wantErr   error
+	}{
+		{
+			name:      "short and medium haul in economy",
+			flights:   []api.Flight{{Start: "ATL", End: "EWR"}, {Start: "SFO", End: "ATL"}},
+			wantCabin: "economy",
+			wantBands: []string{"medium", "short"},
+			wantKg:    []float64{485.9, 202.1},
+			wantTotal: 688.0,
+		},
+		{
+			name:      "long haul in economy",
+			flights:   []api.Flight{{Start: "JFK", End: "LHR"}},
+			wantCabin: "economy",
+			wantBands: []string{"long"},
+			wantKg:    []float64{688.1},
+			wantTotal: 688.1,
+		},
+		{
+			name:      "long haul in business",
+			flights:   []api.Flight{{Start: "JFK", End: "LHR"}},
+			cabin:     "Business",
+			wantCabin: "business",
+			wantBands: []string{"long"},
+			wantKg:    []float64{1995.5},
+			wantTotal: 1995.5,
+		},
+		{
+			name:    "unknown cabin",
+			flights: []api.Flight{{Start: "JFK", End: "LHR"}},
+			cabin:   "cargo",
+			wantErr: errUnknownCabin,
+		},
+		{
+			name:    "unknown airport",
+			flights: []api.Flight{{Start: "JFK", End: "XXX"}},
+			wantErr: ErrUnknownAirport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itinerary, err := ReconstructItinerary(tt.flights)
+			if err != nil {
+				t.Fatalf("ReconstructItinerary: %v", err)
+			}
+			got, err := EstimateEmissions(itinerary, reg, tt.cabin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Cabin != tt.wantCabin {
+				t.Errorf("Cabin = %q, want %q", got.Cabin, tt.wantCabin)
+			}
+			if len(got.Legs) != len(tt.wantKg) {
+				t.Fatalf("legs = %+v, want %d", got.Legs, len(tt.wantKg))
+			}
+			for i, leg := range got.Legs {
+				if leg.Band != tt.wantBands[i] {
+					t.Errorf("leg %d band = %q, want %q", i, leg.Band, tt.wantBands[i])
+				}
+				if leg.KgCO2 != tt.wantKg[i] {
+					t.Errorf("leg %d KgCO2 = %v, want %v", i, leg.KgCO2, tt.wantKg[i])
+				}
+			}
+			if got.TotalKgCO2 != tt.wantTotal {
+				t.Errorf("TotalKgCO2 = %v, want %v", got.TotalKgCO2, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestHaulBand(t *testing.T) {
+	tests := []struct {
+		km   float64
+		want string
+	}{
+		{0, "short"},
+		{shortHaulMaxKm - 1, "short"},
+		{shortHaulMaxKm, "medium"},
+		{mediumHaulMaxKm - 1, "medium"},
+		{mediumHaulMaxKm, "long"},
+		{15000, "long"},
+	}
+	for _, tt := range tests {
+		if got, _ := haulBand(tt.km); got != tt.want {
+			t.Errorf("haulBand(%v) = %q, want %q", tt.km, got, tt.want)
+		}
+	}
+}
+
+func TestFlightEmissions(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantCabin  string
+	}{
+		{
+			name:       "default cabin",
+			body:       `[["JFK","LHR"]]`,
+			wantStatus: http.StatusOK,
+			wantCabin:  "economy",
+		},
+		{
+			name:       "cabin from body options",
+			body:       `{"segments":[{"from":"JFK","to":"LHR"}],"options":{"cabin":"first"}}`,
+			wantStatus: http.StatusOK,
+			wantCabin:  "first",
+		},
+		{
+			name:       "cabin query parameter wins",
+			target:     "/calculate/emissions?cabin=business",
+			body:       `{"segments":[{"from":"JFK","to":"LHR"}],"options":{"cabin":"first"}}`,
+			wantStatus: http.StatusOK,
+			wantCabin:  "business",
+		},
+		{
+			name:       "unknown cabin returns 400",
+			target:     "/calculate/emissions?cabin=cargo",
+			body:       `[["JFK","LHR"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/calculate/emissions"
+			}
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			if err := New().FlightEmissions(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got api.Emissions
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got.Cabin != tt.wantCabin || got.TotalKgCO2 <= 0 {
+				t.Errorf("emissions = %+v, want cabin %q with a positive total", got, tt.wantCabin)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// ErrNoEulerianPath is returned when the segments cannot be flown as one
+// itinerary that uses every segment exactly once. The concrete error is an
+// *EulerianError explaining why.
+var ErrNoEulerianPath = errors.New("no eulerian path: segments cannot be flown as a single itinerary using each exactly once")
+
+// EulerianError explains why FindEulerianItinerary found no itinerary. When
+// the degree condition fails, Airports lists every airport whose outgoing and
+// incoming segment counts rule a path out; when the segments are not all
+// reachable from the start airport, Indexes lists the unreachable ones. It
+// matches ErrNoEulerianPath via errors.Is.
+type EulerianError struct {
+	Airports []string
+	Indexes  []int
+}
+
+func (e *EulerianError) Error() string {
+	if len(e.Airports) > 0 {
+		return fmt.Sprintf("no eulerian path: airports %v have unbalanced outgoing/incoming flight counts", e.Airports)
+	}
+	return fmt.Sprintf("no eulerian path: segments %v are unreachable from the start airport", e.Indexes)
+}
+
+// Is reports whether target is ErrNoEulerianPath.
+func (e *EulerianError) Is(target error) bool {
+	return target == ErrNoEulerianPath
+}
+
+// FindEulerianItinerary orders a multigraph of flight segments — airports may
+// be visited more than once and identical legs may repeat — into a single
+// itinerary that flies every segment exactly once (Hierholzer's algorithm).
+//
+// A path exists when at most one airport has one more outgoing than incoming
+// segment (the start), at most one has one more incoming than outgoing (the
+// end), every other airport is balanced, and every segment is reachable from
+// the start. When every airport is balanced the itinerary is a closed loop
+// and, as with FindItineraryFrom, anchor names the airport to open it at;
+// without an anchor such input is rejected with a *CircularError.
+// Violations are reported as an *EulerianError. Among valid itineraries the
+// result is deterministic: segments are tried in input order. Timestamps do
+// not steer the search, but a result whose consecutive legs contradict them
+// is rejected with a *ChronologyError.
+// Empty input returns an empty Itinerary and a nil error.
+// Time complexity: O(n), space complexity: O(n).
+func FindEulerianItinerary(flights []api.Flight, anchor string) (api.Itinerary, error) {
+	if len(flights) == 0 {
+		return api.Itinerary{}, nil
+	}
+
+	// adj lists each airport's outgoing segment indexes in input order;
+	// airports records first-seen order so diagnostics are deterministic.
+	adj := make(map[string][]int, len(flights))
+	balance := make(map[string]int, len(flights)+1)
+	airports := make([]string, 0, len(flights)+1)
+	for i, f := range flights {
+		for _, a := range []string{f.Start, f.End} {
+			if _, ok := balance[a]; !ok {
+				balance[a] = 0
+				airports = append(airports, a)
+			}
+		}
+		adj[f.Start] = append(adj[f.Start], i)
+		balance[f.Start]++
+		balance[f.End]--
+	}
+
+	start, err := eulerianStart(airports, balance, anchor, adj)
+	if errors.Is(err, ErrCircularPath) {
+		ce := &CircularError{Airports: airports, Indexes: make([]int, len(flights))}
+		for i := range ce.Indexes {
+			ce.Indexes[i] = i
+		}
+		return api.Itinerary{}, ce
+	}
+	if err != nil {
+		return api.Itinerary{}, err
+	}
+
+	// Iterative Hierholzer: extend the current trail greedily; when an
+	// airport runs out of unused segments, pop the segment that reached it
+	// onto the circuit. The circuit is produced in reverse.
+	used := make([]bool, len(flights))
+	cursor := make(map[string]int, len(adj))
+	stack := []string{start}
+	via := []int{-1}
+	legIdx := make([]int, 0, len(flights))
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if out := adj[top]; cursor[top] < len(out) {
+			e := out[cursor[top]]
+			cursor[top]++
+			used[e] = true
+			stack = append(stack, flights[e].End)
+			via = append(via, e)
+			continue
+		}
+		if e := via[len(via)-1]; e >= 0 {
+			legIdx = append(legIdx, e)
+		}
+		stack = stack[:len(stack)-1]
+		via = via[:len(via)-1]
+	}
+
+	if len(legIdx) < len(flights) {
+		ee := &EulerianError{}
+		for i, ok := range used {
+			if !ok {
+				ee.Indexes = append(ee.Indexes, i)
+			}
+		}
+		return api.Itinerary{}, ee
+	}
+
+	slices.Reverse(legIdx)
+	return legsItinerary(flights, legIdx)
+}
+
+// eulerianStart applies the degree condition and returns the airport the
+// itinerary must start from.
+func eulerianStart(airports []string, balance map[string]int, anchor string, adj map[string][]int) (string, error) {
+	var start string
+	var plus, minus int
+	var unbalanced []string
+	for _, a := range airports {
+		d := balance[a]
+		if d == 0 {
+			continue
+		}
+		unbalanced = append(unbalanced, a)
+		switch d {
+		case 1:
+			plus++
+			start = a
+		case -1:
+			minus++
+		}
+	}
+	if plus > 1 || minus > 1 || len(unbalanced) != plus+minus {
+		return "", &EulerianError{Airports: unbalanced}
+	}
+	if start != "" {
+		return start, nil
+	}
+	// Every airport is balanced: a closed loop that needs an anchor.
+	if anchor == "" {
+		return "", ErrCircularPath
+	}
+	if len(adj[anchor]) == 0 {
+		return "", ErrAnchorNotFound
+	}
+	return anchor, nil
+}
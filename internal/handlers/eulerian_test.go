@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestFindEulerianItinerary(t *testing.T) {
+	tests := []struct {
+		name     string
+		flights  []api.Flight
+		anchor   string
+		wantPath []string
+		wantErr  error
+	}{
+		{
+			name:     "empty input",
+			flights:  []api.Flight{},
+			wantPath: nil,
+		},
+		{
+			name: "simple path matches ReconstructItinerary",
+			flights: []api.Flight{
+				{Start: "IND", End: "EWR"},
+				{Start: "SFO", End: "ATL"},
+				{Start: "GSO", End: "IND"},
+				{Start: "ATL", End: "GSO"},
+			},
+			wantPath: []string{"SFO", "ATL", "GSO", "IND", "EWR"},
+		},
+		{
+			name: "hub revisited",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "C"},
+				{Start: "C", End: "B"},
+				{Start: "B", End: "D"},
+			},
+			wantPath: []string{"A", "B", "C", "B", "D"},
+		},
+		{
+			name: "hub revisited with the dead-end leg listed first",
+			flights: []api.Flight{
+				{Start: "B", End: "D"},
+				{Start: "C", End: "B"},
+				{Start: "A", End: "B"},
+				{Start: "B", End: "C"},
+			},
+			wantPath: []string{"A", "B", "C", "B", "D"},
+		},
+		{
+			name: "duplicate legs are each flown once",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "A"},
+				{Start: "A", End: "B"},
+			},
+			wantPath: []string{"A", "B", "A", "B"},
+		},
+		{
+			name: "round trip through a hub opened at the anchor",
+			flights: []api.Flight{
+				{Start: "SFO", End: "DEN"},
+				{Start: "DEN", End: "JFK"},
+				{Start: "JFK", End: "DEN"},
+				{Start: "DEN", End: "SFO"},
+			},
+			anchor:   "SFO",
+			wantPath: []string{"SFO", "DEN", "JFK", "DEN", "SFO"},
+		},
+		{
+			name: "closed loop without an anchor is rejected",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "A"},
+			},
+			wantErr: ErrCircularPath,
+		},
+		{
+			name: "closed loop with an unknown anchor is rejected",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "B", End: "A"},
+			},
+			anchor:  "C",
+			wantErr: ErrAnchorNotFound,
+		},
+		{
+			name: "two outgoing flights from the start is unbalanced",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "A", End: "C"},
+			},
+			wantErr: ErrNoEulerianPath,
+		},
+		{
+			name: "detached loop is unreachable",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "C", End: "D"},
+				{Start: "D", End: "C"},
+			},
+			wantErr: ErrNoEulerianPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindEulerianItinerary(tt.flights, tt.anchor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FindEulerianItinerary() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !slices.Equal(got.Path, tt.wantPath) {
+				t.Errorf("FindEulerianItinerary() path = %v, want %v", got.Path, tt.wantPath)
+			}
+			if len(got.Legs) != len(tt.flights) {
+				t.Fatalf("FindEulerianItinerary() legs = %d, want %d", len(got.Legs), len(tt.flights))
+			}
+			for i, leg := range got.Legs {
+				if leg.Start != got.Path[i] || leg.End != got.Path[i+1] {
+					t.Errorf("leg %d = %s->%s, want %s->%s", i, leg.Start, leg.End, got.Path[i], got.Path[i+1])
+				}
+			}
+		})
+	}
+}
+
+func TestFindEulerianItineraryError(t *testing.T) {
+	t.Run("unbalanced airports are listed", func(t *testing.T) {
+		_, err := FindEulerianItinerary([]api.Flight{
+			{Start: "A", End: "B"},
+			{Start: "A", End: "C"},
+		}, "")
+		var ee *EulerianError
+		if !errors.As(err, &ee) {
+			t.Fatalf("err = %v, want *EulerianError", err)
+		}
+		if want := []string{"A", "B", "C"}; !slices.Equal(ee.Airports, want) {
+			t.Errorf("Airports = %v, want %v", ee.Airports, want)
+		}
+	})
+	t.Run("unreachable segments are listed", func(t *testing.T) {
+		_, err := FindEulerianItinerary([]api.Flight{
+			{Start: "A", End: "B"},
+			{Start: "C", End: "D"},
+			{Start: "D", End: "C"},
+		}, "")
+		var ee *EulerianError
+		if !errors.As(err, &ee) {
+			t.Fatalf("err = %v, want *EulerianError", err)
+		}
+		if want := []int{1, 2}; !slices.Equal(ee.Indexes, want) {
+			t.Errorf("Indexes = %v, want %v", ee.Indexes, want)
+		}
+	})
+}
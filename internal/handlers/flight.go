@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v5"
@@ -15,69 +17,360 @@ const errorKey = "Error"
 // per-segment validation errors.
 const indexKey = "Index"
 
+// airportKey is the JSON field naming the offending airport in structural
+// itinerary errors (e.g. ErrBranchingPath).
+const airportKey = "Airport"
+
+// indexesKey is the JSON field listing every segment index involved in a
+// structural itinerary error.
+const indexesKey = "Indexes"
+
+// airportsKey is the JSON field listing the airports involved in a circular
+// path or ruling out an Eulerian itinerary.
+const airportsKey = "Airports"
+
+// startCandidatesKey and endCandidatesKey are the JSON fields listing every
+// airport with no incoming (start) or no outgoing (end) segment in
+// disconnected-graph errors.
+const (
+	startCandidatesKey = "StartCandidates"
+	endCandidatesKey   = "EndCandidates"
+)
+
+// componentsKey is the JSON field listing the groups of airports left off the
+// itinerary path in disconnected-graph errors.
+const componentsKey = "Components"
+
+// jobKey is the JSON field carrying the job's current state when a job
+// request cannot be carried out (e.g. canceling a finished job).
+const jobKey = "Job"
+
+// anchorParam is the query parameter naming the home airport used to break
+// round trips (see FindItineraryFrom).
+const anchorParam = "anchor"
+
+// modeParam is the query parameter selecting the itinerary solver.
+const modeParam = "mode"
+
+// cabinParam is the query parameter selecting the cabin class for emission
+// estimates (see EstimateEmissions).
+const cabinParam = "cabin"
+
+// sourcesParam is the query parameter ranking record sources, most trusted
+// first, for merging source-tagged segments (see MergeSources).
+const sourcesParam = "sources"
+
+// Solver modes accepted by modeParam. modePath (the default) solves a simple
+// path where every airport is visited at most once; modeEulerian accepts
+// repeated airports and duplicate legs (see FindEulerianItinerary).
+const (
+	modePath     = "path"
+	modeEulerian = "eulerian"
+)
+
+// errUnknownMode is returned for an unrecognised modeParam value.
+var errUnknownMode = errors.New("unknown mode")
+
 // FlightCalculate godoc
 // @Summary Determine the flight path of a person.
-// @Description get the flight path of a person.
+// @Description get the flight path of a person. Airport codes are trimmed, upper-cased and mapped from ICAO to IATA before solving. A legacy array body returns [start, end]; a CalculateRequest object body returns an api.CalculateResponse that also lists the rewritten codes. Segments tagged with a source are merged first: records of the same leg (same flight number and date, or same airports) from several sources are solved as one, the most trusted source winning, and the response lists the merges.
 // @Tags FlightCalculate
 // @ID flightCalculate-get
 // @Accept json
 // @Produce json
-// @Param   flightSegments	body	[][]string	true	"Flight segments"
-// @Success 200 {object} []string
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Success 200 {object} []string	"[start, end] (legacy array body) or api.CalculateResponse (object body)"
 // @Failure 400 {object} map[string]interface{}	"Bad Request"
 // @Failure 500 {object} map[string]interface{}	"Internal Server Error"
 // @Router /calculate [post].
 func (h Handler) FlightCalculate(c *echo.Context) error {
-	var payload [][]string
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
 
-	// bind payload
-	err := c.Bind(&payload)
+	itinerary, err := solveItinerary(calc.flights, calc.options.Mode, calc.options.Anchor)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]any{
-			errorKey: "Can't parse the payload",
-		})
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
 	}
 
-	// validate payload
-	if len(payload) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]any{
-			errorKey: "Flight segments cannot be empty",
-		})
+	start, end := itinerary.Path[0], itinerary.Path[len(itinerary.Path)-1]
+	if calc.object {
+		return c.JSON(http.StatusOK, api.CalculateResponse{Start: start, End: end, Rewrites: calc.rewrites, Merges: calc.merges})
 	}
+	return c.JSON(http.StatusOK, []string{start, end})
+}
 
-	flights := make([]api.Flight, 0, len(payload))
-	for i, v := range payload {
-		if len(v) < 2 {
-			return c.JSON(http.StatusBadRequest, map[string]any{
-				errorKey: "Each flight segment must contain both source and destination",
-				indexKey: i,
-			})
-		}
-		src, dst := v[0], v[1]
-		if src == "" || dst == "" {
-			return c.JSON(http.StatusBadRequest, map[string]any{
-				errorKey: "Airport codes must be non-empty",
-				indexKey: i,
-			})
-		}
-		if src == dst {
-			return c.JSON(http.StatusBadRequest, map[string]any{
-				errorKey: "Source and destination airports must differ",
-				indexKey: i,
-			})
-		}
-		flights = append(flights, api.Flight{
-			Start: src,
-			End:   dst,
-		})
+// FlightItinerary godoc
+// @Summary Reconstruct the full ordered itinerary of a person.
+// @Description get every airport and segment of the flight path in travel order, listing the airport codes that were normalized (trimmed, upper-cased, ICAO mapped to IATA) and the source-tagged records that were merged or discarded before solving.
+// @Tags FlightCalculate
+// @ID flightItinerary-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Success 200 {object} api.Itinerary
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/itinerary [post].
+func (h Handler) FlightItinerary(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	itinerary, err := solveItinerary(calc.flights, calc.options.Mode, calc.options.Anchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+	itinerary.Rewrites = calc.rewrites
+	itinerary.Merges = calc.merges
+
+	return c.JSON(http.StatusOK, itinerary)
+}
+
+// FlightSummary godoc
+// @Summary Summarize the distances and flight times of an itinerary.
+// @Description reconstruct the itinerary, then report per-leg and total great-circle distance (km, mi, nm), the direct origin-to-destination distance, the detour ratio, estimated and scheduled flight times, and the CO2 estimate (see /calculate/emissions). Every airport must be in the embedded airport dataset.
+// @Tags FlightCalculate
+// @ID flightSummary-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Param   cabin	query	string	false	"Cabin class for the emissions estimate (default economy)"	Enums(economy, premium_economy, business, first)
+// @Success 200 {object} api.Summary
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/summary [post].
+func (h Handler) FlightSummary(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	itinerary, err := solveItinerary(calc.flights, calc.options.Mode, calc.options.Anchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	summary, err := SummarizeItinerary(itinerary, h.airports)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+	summary.Emissions, err = EstimateEmissions(itinerary, h.airports, calc.options.Cabin)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+// FlightEmissions godoc
+// @Summary Estimate the CO2 emissions of an itinerary.
+// @Description reconstruct the itinerary, then estimate per-passenger CO2 per leg and in total with the distance-band method (short/medium/long haul factors, 8% distance uplift) and a cabin-class multiplier. Every airport must be in the embedded airport dataset.
+// @Tags FlightCalculate
+// @ID flightEmissions-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Param   cabin	query	string	false	"Cabin class (default economy)"	Enums(economy, premium_economy, business, first)
+// @Success 200 {object} api.Emissions
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/emissions [post].
+func (h Handler) FlightEmissions(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	itinerary, err := solveItinerary(calc.flights, calc.options.Mode, calc.options.Anchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	emissions, err := EstimateEmissions(itinerary, h.airports, calc.options.Cabin)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	return c.JSON(http.StatusOK, emissions)
+}
+
+// FlightCountries godoc
+// @Summary List the countries and border crossings of an itinerary.
+// @Description reconstruct the itinerary, then map every airport to its ISO 3166-1 alpha-2 country and report the countries in travel order, the international border crossings, and whether the trip is domestic-only. Every airport must be in the embedded airport dataset.
+// @Tags FlightCalculate
+// @ID flightCountries-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Success 200 {object} api.CountryReport
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/countries [post].
+func (h Handler) FlightCountries(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	itinerary, err := solveItinerary(calc.flights, calc.options.Mode, calc.options.Anchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	report, err := TraceCountries(itinerary, h.airports)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// FlightComponents godoc
+// @Summary Split a multi-trip payload into separate itineraries.
+// @Description partition the segments into connected components and solve each one on its own, listing the segment indexes that belong to it.
+// @Tags FlightCalculate
+// @ID flightComponents-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Param   anchor	query	string	false	"Home airport used to break round trips (only consulted for circular components)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Success 200 {array} api.Component
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/components [post].
+func (h Handler) FlightComponents(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	solve, err := solverFor(calc.options.Mode, calc.options.Anchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	return c.JSON(http.StatusOK, SplitItineraries(calc.flights, solve))
+}
+
+// FlightGaps godoc
+// @Summary Suggest the missing segments that would connect a broken itinerary.
+// @Description list the partial paths found in the segments, every end-to-start connection between them, and the smallest set of bridging segments that joins them into a single path.
+// @Tags FlightCalculate
+// @ID flightGaps-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination] with optional RFC 3339 [departure, arrival]"
+// @Success 200 {object} api.GapAnalysis
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/gaps [post].
+func (h Handler) FlightGaps(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
 	}
 
-	start, finish, err := FindItinerary(flights)
+	gaps, err := SuggestBridges(calc.flights)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]any{
-			errorKey: err.Error(),
-		})
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
 	}
 
-	return c.JSON(http.StatusOK, []string{start, finish})
+	return c.JSON(http.StatusOK, gaps)
+}
+
+// solveItinerary dispatches to the solver selected by mode.
+func solveItinerary(flights []api.Flight, mode, anchor string) (api.Itinerary, error) {
+	solve, err := solverFor(mode, anchor)
+	if err != nil {
+		return api.Itinerary{}, err
+	}
+	return solve(flights)
+}
+
+// solverFor returns the solver selected by mode, bound to anchor. Segments
+// that all carry a departure time are ordered by ReconstructTimedItinerary
+// instead, whatever the mode.
+func solverFor(mode, anchor string) (func([]api.Flight) (api.Itinerary, error), error) {
+	var solve func([]api.Flight) (api.Itinerary, error)
+	switch mode {
+	case "", modePath:
+		solve = func(flights []api.Flight) (api.Itinerary, error) {
+			return ReconstructItineraryFrom(flights, anchor)
+		}
+	case modeEulerian:
+		solve = func(flights []api.Flight) (api.Itinerary, error) {
+			return FindEulerianItinerary(flights, anchor)
+		}
+	default:
+		return nil, fmt.Errorf("%w %q: want %q or %q", errUnknownMode, mode, modePath, modeEulerian)
+	}
+	return func(flights []api.Flight) (api.Itinerary, error) {
+		if allTimed(flights) {
+			return ReconstructTimedItinerary(flights)
+		}
+		return solve(flights)
+	}, nil
+}
+
+// itineraryErrorBody maps an itinerary error to its 400 body, adding the
+// diagnostic fields carried by typed errors alongside the message.
+func itineraryErrorBody(err error) map[string]any {
+	body := map[string]any{
+		errorKey: err.Error(),
+	}
+	var branching *BranchingError
+	if errors.As(err, &branching) {
+		body[airportKey] = branching.Airport
+		body[indexesKey] = branching.Indexes
+	}
+	var circular *CircularError
+	if errors.As(err, &circular) {
+		body[airportsKey] = circular.Airports
+		body[indexesKey] = circular.Indexes
+	}
+	var disconnected *DisconnectedError
+	if errors.As(err, &disconnected) {
+		body[startCandidatesKey] = disconnected.StartCandidates
+		body[endCandidatesKey] = disconnected.EndCandidates
+		body[componentsKey] = disconnected.Components
+		body[indexesKey] = disconnected.Indexes
+	}
+	var chronology *ChronologyError
+	if errors.As(err, &chronology) {
+		body[indexesKey] = chronology.Indexes
+	}
+	var unknown *UnknownAirportError
+	if errors.As(err, &unknown) {
+		body[airportKey] = unknown.Airport
+	}
+	var eulerian *EulerianError
+	if errors.As(err, &eulerian) {
+		if len(eulerian.Airports) > 0 {
+			body[airportsKey] = eulerian.Airports
+		}
+		if len(eulerian.Indexes) > 0 {
+			body[indexesKey] = eulerian.Indexes
+		}
+	}
+	return body
 }
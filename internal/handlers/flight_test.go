@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
 )
 
 func TestFlightCalculate(t *testing.T) {
@@ -102,3 +105,132 @@ func TestFlightCalculate(t *testing.T) {
 		})
 	}
 }
+
+func TestFlightItinerary(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantPath   []string
+	}{
+		{
+			name:       "single segment SFO to EWR",
+			body:       `[["SFO", "EWR"]]`,
+			wantStatus: http.StatusOK,
+			wantPath:   []string{"SFO", "EWR"},
+		},
+		{
+			name:       "four segments shuffled",
+			body:       `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
+			wantStatus: http.StatusOK,
+			wantPath:   []string{"SFO", "ATL", "GSO", "IND", "EWR"},
+		},
+		{
+			name:       "empty array returns 400",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "circular path returns 400",
+			body:       `[["A","B"],["B","A"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "circular path with anchor returns the loop",
+			target:     "/calculate/itinerary?anchor=B",
+			body:       `[["A","B"],["B","A"]]`,
+			wantStatus: http.StatusOK,
+			wantPath:   []string{"B", "A", "B"},
+		},
+		{
+			name:       "eulerian mode revisits a hub",
+			target:     "/calculate/itinerary?mode=eulerian",
+			body:       `[["A","B"],["B","C"],["C","B"],["B","D"]]`,
+			wantStatus: http.StatusOK,
+			wantPath:   []string{"A", "B", "C", "B", "D"},
+		},
+		{
+			name:       "default mode rejects a revisited hub",
+			body:       `[["A","B"],["B","C"],["C","B"],["B","D"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "timestamps order a round trip without an anchor",
+			body: `[["EWR","SFO","2026-03-05T18:00:00Z","2026-03-05T21:30:00Z"],
+				["SFO","EWR","2026-03-01T08:00:00Z","2026-03-01T16:30:00Z"]]`,
+			wantStatus: http.StatusOK,
+			wantPath:   []string{"SFO", "EWR", "SFO"},
+		},
+		{
+			name: "connection departing before the previous leg lands returns 400",
+			body: `[["ATL","EWR","2026-03-01T10:00:00Z","2026-03-01T12:00:00Z"],
+				["SFO","ATL","2026-03-01T08:00:00Z","2026-03-01T11:00:00Z"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed timestamp returns 400",
+			body:       `{"segments":[{"from":"SFO","to":"EWR","departs":"2026-03-01 08:00"}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "non-time extra elements are ignored",
+			body:       `[["SFO","EWR","JFK"]]`,
+			wantStatus: http.StatusOK,
+			wantPath:   []string{"SFO", "EWR"},
+		},
+		{
+			name:       "arrival before departure returns 400",
+			body:       `[["SFO","EWR","2026-03-01T08:00:00Z","2026-03-01T07:00:00Z"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown mode returns 400",
+			target:     "/calculate/itinerary?mode=fastest",
+			body:       `[["SFO","EWR"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed JSON returns 400",
+			body:       `not json`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			target := tt.target
+			if target == "" {
+				target = "/calculate/itinerary"
+			}
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := New()
+			err := h.FlightItinerary(c)
+			if err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				var got api.Itinerary
+				if jsonErr := json.Unmarshal(rec.Body.Bytes(), &got); jsonErr != nil {
+					t.Fatalf("failed to unmarshal response: %v", jsonErr)
+				}
+				if !slices.Equal(got.Path, tt.wantPath) {
+					t.Errorf("path = %v, want %v", got.Path, tt.wantPath)
+				}
+				if len(got.Legs) != len(tt.wantPath)-1 {
+					t.Errorf("legs = %d, want %d", len(got.Legs), len(tt.wantPath)-1)
+				}
+			}
+		})
+	}
+}
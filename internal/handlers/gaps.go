@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// ErrUnbridgeable is returned by SuggestBridges when no set of added segments
+// can turn the input into a single path: an airport already branches, or some
+// segments form a closed loop whose airports cannot take another connection.
+// It wraps the underlying itinerary error, so errors.As still reaches the
+// diagnostic detail.
+var ErrUnbridgeable = errors.New("unbridgeable itinerary: adding segments cannot form a single path")
+
+// SuggestBridges performs a gap analysis on input that FindItinerary rejects
+// as disconnected, e.g. because a ticket is missing from the records. It
+// splits the segments into the partial paths (fragments) walked from each
+// start candidate, lists the airports where fragments end and start (any end
+// can be bridged to any start of another fragment), and proposes the smallest
+// bridging set: k-1 segments that chain the k fragments, in order of their
+// start airports. A valid single path yields one fragment and no bridges.
+// Time complexity: O(n + k log k) for k fragments, space complexity: O(n).
+func SuggestBridges(flights []api.Flight) (api.GapAnalysis, error) {
+	if len(flights) == 0 {
+		return api.GapAnalysis{}, nil
+	}
+
+	next, prev, err := linkSegments(flights)
+	if err != nil {
+		return api.GapAnalysis{}, fmt.Errorf("%w: %w", ErrUnbridgeable, err)
+	}
+	starts, ends := endpoints(next, prev)
+	if len(starts) == 0 {
+		return api.GapAnalysis{}, fmt.Errorf("%w: %w", ErrUnbridgeable, newCircularError(flights, next))
+	}
+	slices.Sort(starts)
+
+	fragments := make([][]string, 0, len(starts))
+	covered := 0
+	for _, s := range starts {
+		fragment := walk(next, s)
+		covered += len(fragment) - 1
+		fragments = append(fragments, fragment)
+	}
+	if covered < len(next) {
+		// Segments left over after walking every fragment form closed loops.
+		var path []string
+		if len(fragments) == 1 {
+			path = fragments[0]
+		}
+		return api.GapAnalysis{}, fmt.Errorf("%w: %w", ErrUnbridgeable, newDisconnectedError(flights, next, path, starts, ends))
+	}
+
+	gaps := api.GapAnalysis{Fragments: fragments, Starts: starts, Ends: make([]string, 0, len(fragments))}
+	for i, from := range fragments {
+		gaps.Ends = append(gaps.Ends, from[len(from)-1])
+		if i > 0 {
+			prevFragment := fragments[i-1]
+			gaps.Bridges = append(gaps.Bridges, api.Flight{Start: prevFragment[len(prevFragment)-1], End: from[0]})
+		}
+	}
+	slices.Sort(gaps.Ends)
+	return gaps, nil
+}
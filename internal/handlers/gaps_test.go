@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestSuggestBridges(t *testing.T) {
+	tests := []struct {
+		name          string
+		flights       []api.Flight
+		wantFragments [][]string
+		wantEnds      []string
+		wantStarts    []string
+		wantBridges   []api.Flight
+		wantErr       error
+	}{
+		{
+			name:          "valid path needs no bridge",
+			flights:       []api.Flight{{Start: "ATL", End: "EWR"}, {Start: "SFO", End: "ATL"}},
+			wantFragments: [][]string{{"SFO", "ATL", "EWR"}},
+			wantEnds:      []string{"EWR"},
+			wantStarts:    []string{"SFO"},
+		},
+		{
+			name: "missing middle ticket",
+			flights: []api.Flight{
+				{Start: "IND", End: "EWR"},
+				{Start: "SFO", End: "ATL"},
+				{Start: "GSO", End: "IND"},
+			},
+			wantFragments: [][]string{{"GSO", "IND", "EWR"}, {"SFO", "ATL"}},
+			wantEnds:      []string{"ATL", "EWR"},
+			wantStarts:    []string{"GSO", "SFO"},
+			wantBridges:   []api.Flight{{Start: "EWR", End: "SFO"}},
+		},
+		{
+			name: "three fragments need two bridges",
+			flights: []api.Flight{
+				{Start: "C", End: "D"},
+				{Start: "A", End: "B"},
+				{Start: "E", End: "F"},
+			},
+			wantFragments: [][]string{{"A", "B"}, {"C", "D"}, {"E", "F"}},
+			wantEnds:      []string{"B", "D", "F"},
+			wantStarts:    []string{"A", "C", "E"},
+			wantBridges:   []api.Flight{{Start: "B", End: "C"}, {Start: "D", End: "E"}},
+		},
+		{
+			name:    "branching input cannot be bridged",
+			flights: []api.Flight{{Start: "A", End: "B"}, {Start: "A", End: "C"}},
+			wantErr: ErrBranchingPath,
+		},
+		{
+			name:    "closed loop cannot be bridged",
+			flights: []api.Flight{{Start: "A", End: "B"}, {Start: "B", End: "A"}},
+			wantErr: ErrCircularPath,
+		},
+		{
+			name: "detached loop beside fragments cannot be bridged",
+			flights: []api.Flight{
+				{Start: "A", End: "B"},
+				{Start: "C", End: "D"},
+				{Start: "X", End: "Y"},
+				{Start: "Y", End: "X"},
+			},
+			wantErr: ErrDisconnectedGraph,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SuggestBridges(tt.flights)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SuggestBridges() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, ErrUnbridgeable) {
+					t.Errorf("SuggestBridges() err = %v, want it to wrap ErrUnbridgeable", err)
+				}
+				return
+			}
+			if !slices.EqualFunc(got.Fragments, tt.wantFragments, slices.Equal[[]string]) {
+				t.Errorf("Fragments = %v, want %v", got.Fragments, tt.wantFragments)
+			}
+			if !slices.Equal(got.Ends, tt.wantEnds) || !slices.Equal(got.Starts, tt.wantStarts) {
+				t.Errorf("Ends, Starts = %v, %v, want %v, %v", got.Ends, got.Starts, tt.wantEnds, tt.wantStarts)
+			}
+			if !slices.Equal(got.Bridges, tt.wantBridges) {
+				t.Errorf("Bridges = %v, want %v", got.Bridges, tt.wantBridges)
+			}
+			// Adding the bridges must make the input a valid single path.
+			if _, _, err := FindItinerary(append(slices.Clone(tt.flights), got.Bridges...)); err != nil {
+				t.Errorf("input + bridges is still invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestFlightGaps(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantBridges int
+	}{
+		{
+			name:        "missing middle ticket",
+			body:        `[["IND","EWR"],["SFO","ATL"],["GSO","IND"]]`,
+			wantStatus:  http.StatusOK,
+			wantBridges: 1,
+		},
+		{
+			name:       "branching input returns 400",
+			body:       `[["A","B"],["A","C"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "empty array returns 400",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate/gaps", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := New()
+			if err := h.FlightGaps(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got api.GapAnalysis
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if len(got.Bridges) != tt.wantBridges {
+				t.Errorf("bridges = %v, want %d", got.Bridges, tt.wantBridges)
+			}
+		})
+	}
+}
+
+func TestFlightGapsResponseSizeIsLinear(t *testing.T) {
+	// Disjoint segments are the worst case: every segment is its own
+	// fragment, so a pairwise listing would grow with the square of the input.
+	const segments = 3000
+	var body strings.Builder
+	body.WriteString("[")
+	for i := range segments {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `["A%d","B%d"]`, i, i)
+	}
+	body.WriteString("]")
+
+	rec := serveRequest(t, New().FlightGaps, http.MethodPost, "/calculate/gaps", "", body.String())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %.200s", rec.Code, rec.Body.String())
+	}
+	// Fragments, Ends, Starts and Bridges each mention every airport a
+	// bounded number of times, so the response stays a small multiple of the
+	// request.
+	if got, limit := rec.Body.Len(), 8*body.Len(); got > limit {
+		t.Errorf("response is %d bytes for a %d-byte request, want at most %d", got, body.Len(), limit)
+	}
+}
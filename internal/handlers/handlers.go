@@ -1,9 +1,125 @@
 package handlers
 
+import (
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/internal/contacts"
+	"github.com/AndriyKalashnykov/flight-path/internal/jobs"
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+)
+
 // Handler contains dependencies for HTTP handlers.
-type Handler struct{}
+type Handler struct {
+	airports       *airports.Registry
+	strictAirports bool
+	batchWorkers   int
+	jobs           *jobs.Queue
+	itineraries    store.Store
+	contacts       *contacts.Index
+	sessions       *sessionRegistry
+	sourcePriority []string
+}
+
+// Option configures a Handler built by New.
+type Option func(*Handler)
+
+// WithAirports sets the airport registry used to validate codes. Defaults to
+// airports.Default().
+func WithAirports(reg *airports.Registry) Option {
+	return func(h *Handler) {
+		h.airports = reg
+	}
+}
+
+// WithStrictAirports makes the calculate endpoints reject airport codes that
+// are not in the registry (strict) instead of accepting any non-empty code
+// (lenient, the default).
+func WithStrictAirports(strict bool) Option {
+	return func(h *Handler) {
+		h.strictAirports = strict
+	}
+}
+
+// WithBatchWorkers bounds the number of itineraries a batch request solves
+// concurrently. Values below 1 are ignored. Defaults to GOMAXPROCS.
+func WithBatchWorkers(n int) Option {
+	return func(h *Handler) {
+		if n > 0 {
+			h.batchWorkers = n
+		}
+	}
+}
+
+// WithJobs sets the queue that runs asynchronous jobs. Defaults to a queue
+// with GOMAXPROCS workers, jobs.DefaultCapacity, jobs.DefaultTTL and
+// callbacks disabled.
+func WithJobs(q *jobs.Queue) Option {
+	return func(h *Handler) {
+		h.jobs = q
+	}
+}
+
+// WithStore sets where POST /itineraries saves itineraries. Defaults to
+// store.NewMemory().
+func WithStore(s store.Store) Option {
+	return func(h *Handler) {
+		h.itineraries = s
+	}
+}
+
+// WithContacts sets the index that contact traces are answered from; it must
+// already hold the store's passengers (see contacts.Load). Defaults to an
+// empty index.
+func WithContacts(x *contacts.Index) Option {
+	return func(h *Handler) {
+		h.contacts = x
+	}
+}
+
+// WithSessions configures incremental sessions: a session is dropped after
+// ttl without a request for it, at most maxSessions may be open at once and
+// each holds at most maxSegments segments. Values below 1 fall back to
+// DefaultSessionTTL, DefaultMaxSessions and DefaultMaxSessionSegments.
+func WithSessions(ttl time.Duration, maxSessions, maxSegments int) Option {
+	return func(h *Handler) {
+		h.sessions = newSessionRegistry(ttl, maxSessions, maxSegments)
+	}
+}
+
+// WithSourcePriority ranks the sources of source-tagged segments, most
+// trusted first, for requests that don't pass their own Sources option.
+// Entries are trimmed and empty ones dropped. Defaults to no ranking, which
+// keeps the first record of each leg.
+func WithSourcePriority(sources []string) Option {
+	return func(h *Handler) {
+		h.sourcePriority = nil
+		for _, s := range sources {
+			if s = strings.TrimSpace(s); s != "" {
+				h.sourcePriority = append(h.sourcePriority, s)
+			}
+		}
+	}
+}
 
 // New creates a new Handler instance.
-func New() Handler {
-	return Handler{}
+func New(opts ...Option) Handler {
+	h := Handler{
+		airports:     airports.Default(),
+		batchWorkers: runtime.GOMAXPROCS(0),
+		itineraries:  store.NewMemory(),
+		sessions:     newSessionRegistry(DefaultSessionTTL, DefaultMaxSessions, DefaultMaxSessionSegments),
+	}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	if h.contacts == nil {
+		h.contacts = contacts.New(h.airports)
+	}
+	if h.jobs == nil {
+		h.jobs = jobs.New(runtime.GOMAXPROCS(0), jobs.DefaultCapacity, jobs.DefaultTTL, nil)
+	}
+	return h
 }
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// offsetParam and limitParam are the pagination query parameters of
+// GET /itineraries.
+const (
+	offsetParam = "offset"
+	limitParam  = "limit"
+)
+
+// defaultPageLimit and maxPageLimit bound the page size of GET /itineraries.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Patch media types accepted by PATCH /itineraries/{id}.
+const (
+	jsonPatchType  = "application/json-patch+json"
+	mergePatchType = "application/merge-patch+json"
+)
+
+// Conditional request headers of PATCH /itineraries/{id}.
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// patchFormats applies a patch document of each accepted media type to a
+// JSON document.
+var patchFormats = map[string]func(doc, patch []byte) ([]byte, error){
+	jsonPatchType:  applyJSONPatch,
+	mergePatchType: jsonpatch.MergePatch,
+}
+
+// errStaleVersion and errPatchRejected abort a store update from inside
+// ItineraryPatch: the If-Match tags don't name the current version, or the
+// patched itinerary is invalid.
+var (
+	errStaleVersion  = errors.New("stale itinerary version")
+	errPatchRejected = errors.New("itinerary patch rejected")
+)
+
+// ItinerarySave godoc
+// @Summary Solve and save an itinerary.
+// @Description solve the flight segments as POST /calculate does and, when they form a valid itinerary, save the normalized segments, the options used and the computed path. Invalid input is rejected with the same 400 responses and nothing is saved.
+// @Tags Itineraries
+// @ID itinerarySave-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Success 201 {object} api.StoredItinerary
+// @Header  201 {string} Location "/itineraries/{id}"
+// @Header  201 {string} ETag "Quoted version number"
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /itineraries [post].
+func (h Handler) ItinerarySave(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	itinerary, err := solveItinerary(calc.flights, calc.options.Mode, calc.options.Anchor)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+
+	saved, err := h.itineraries.Create(api.StoredItinerary{
+		Segments: calc.flights,
+		Options:  api.Options{Mode: calc.options.Mode, Anchor: calc.options.Anchor},
+		Start:    itinerary.Path[0],
+		End:      itinerary.Path[len(itinerary.Path)-1],
+		Path:     itinerary.Path,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't save the itinerary"})
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/itineraries/"+saved.ID)
+	c.Response().Header().Set(headerETag, etag(saved.Version))
+	return c.JSON(http.StatusCreated, saved)
+}
+
+// ItineraryGet godoc
+// @Summary Get a saved itinerary.
+// @Description return the current version of the itinerary saved under the given ID. The ETag header carries its version for If-Match on PATCH.
+// @Tags Itineraries
+// @ID itineraryGet-get
+// @Produce json
+// @Param   id	path	string	true	"Itinerary ID"
+// @Success 200 {object} api.StoredItinerary
+// @Header  200 {string} ETag "Quoted version number"
+// @Failure 404 {object} map[string]interface{}	"Itinerary not found"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /itineraries/{id} [get].
+func (h Handler) ItineraryGet(c *echo.Context) error {
+	it, err := h.itineraries.Get(c.Param("id"))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Itinerary not found"})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't read the itinerary"})
+	}
+	c.Response().Header().Set(headerETag, etag(it.Version))
+	return c.JSON(http.StatusOK, it)
+}
+
+// ItineraryPatch godoc
+// @Summary Edit a saved itinerary.
+// @Description apply a JSON Patch (RFC 6902, application/json-patch+json) or a JSON merge patch (RFC 7396, application/merge-patch+json) to the current version of the itinerary, as returned by GET, to replace, add or remove Segments or change the mode and anchor Options. The result is validated and solved as POST /itineraries does and saved as the next version; invalid edits are rejected with the same 400 responses and nothing is saved. If-Match must carry the ETag of the version being edited (or *); when another edit got there first the request fails with 412 and the current ETag.
+// @Tags Itineraries
+// @ID itineraryPatch-patch
+// @Accept application/json-patch+json,application/merge-patch+json
+// @Produce json
+// @Param   id	path	string	true	"Itinerary ID"
+// @Param   If-Match	header	string	true	"ETag of the version being edited"
+// @Param   patch	body	object	true	"JSON Patch array or JSON merge patch object"
+// @Success 200 {object} api.StoredItinerary
+// @Header  200 {string} ETag "Quoted version number"
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 404 {object} map[string]interface{}	"Itinerary not found"
+// @Failure 412 {object} map[string]interface{}	"Itinerary has changed"
+// @Failure 415 {object} map[string]interface{}	"Unsupported patch format"
+// @Failure 428 {object} map[string]interface{}	"If-Match header is required"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /itineraries/{id} [patch].
+func (h Handler) ItineraryPatch(c *echo.Context) error {
+	ifMatch := c.Request().Header.Get(headerIfMatch)
+	if ifMatch == "" {
+		return c.JSON(http.StatusPreconditionRequired, map[string]any{errorKey: "If-Match header is required"})
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	apply, ok := patchFormats[mediaType]
+	if !ok {
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]any{
+			errorKey: "Content-Type must be " + jsonPatchType + " or " + mergePatchType,
+		})
+	}
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: "Can't parse the payload"})
+	}
+
+	var current int
+	var errBody map[string]any
+	saved, err := h.itineraries.Update(c.Param("id"), func(it api.StoredItinerary) (api.StoredItinerary, error) {
+		current = it.Version
+		if !etagMatches(ifMatch, it.Version) {
+			return api.StoredItinerary{}, errStaleVersion
+		}
+		var next api.StoredItinerary
+		next, errBody = h.patchItinerary(it, patch, apply)
+		if errBody != nil {
+			return api.StoredItinerary{}, errPatchRejected
+		}
+		return next, nil
+	})
+	switch {
+	case errors.Is(err, errStaleVersion):
+		c.Response().Header().Set(headerETag, etag(current))
+		return c.JSON(http.StatusPreconditionFailed, map[string]any{
+			errorKey: "Itinerary has changed: If-Match does not match the current version",
+		})
+	case errors.Is(err, errPatchRejected):
+		return c.JSON(http.StatusBadRequest, errBody)
+	case errors.Is(err, store.ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Itinerary not found"})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't save the itinerary"})
+	}
+	c.Response().Header().Set(headerETag, etag(saved.Version))
+	return c.JSON(http.StatusOK, saved)
+}
+
+// ItineraryVersions godoc
+// @Summary List a saved itinerary's versions.
+// @Description return every version of the itinerary, from the one first saved to the current one. Each PATCH adds a version; earlier ones are never changed.
+// @Tags Itineraries
+// @ID itineraryVersions-get
+// @Produce json
+// @Param   id	path	string	true	"Itinerary ID"
+// @Success 200 {object} api.ItineraryVersions
+// @Failure 404 {object} map[string]interface{}	"Itinerary not found"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /itineraries/{id}/versions [get].
+func (h Handler) ItineraryVersions(c *echo.Context) error {
+	id := c.Param("id")
+	versions, err := h.itineraries.Versions(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Itinerary not found"})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't read the itinerary"})
+	}
+	return c.JSON(http.StatusOK, api.ItineraryVersions{ID: id, Versions: versions})
+}
+
+// patchItinerary applies patch to it and re-validates the result: only
+// Segments and the mode and anchor Options may change (a saved itinerary's
+// segments are already merged and it is not priced, so it keeps no cabin or
+// sources), each segment must pass the checks of
+// POST /itineraries, and the segments must still solve. It returns the
+// patched itinerary with Start, End and Path recomputed, or the 400 error
+// body to send instead.
+func (h Handler) patchItinerary(it api.StoredItinerary, patch []byte, apply func(doc, patch []byte) ([]byte, error)) (api.StoredItinerary, map[string]any) {
+	doc, err := json.Marshal(it)
+	if err != nil {
+		return api.StoredItinerary{}, map[string]any{errorKey: "Can't apply the patch"}
+	}
+	doc, err = apply(doc, patch)
+	if err != nil {
+		return api.StoredItinerary{}, map[string]any{errorKey: "Can't apply the patch: " + err.Error()}
+	}
+	var next api.StoredItinerary
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return api.StoredItinerary{}, map[string]any{errorKey: "Can't parse the patched itinerary"}
+	}
+	if !sameComputedFields(it, next) {
+		return api.StoredItinerary{}, map[string]any{errorKey: "Only Segments and Options can be patched"}
+	}
+	if next.Options.Cabin != "" || len(next.Options.Sources) > 0 {
+		return api.StoredItinerary{}, map[string]any{errorKey: "Only the mode and anchor options can be patched"}
+	}
+
+	if len(next.Segments) == 0 {
+		return api.StoredItinerary{}, map[string]any{errorKey: "Flight segments cannot be empty"}
+	}
+	for i, f := range next.Segments {
+		f.Start, f.End = h.airports.Canonical(f.Start), h.airports.Canonical(f.End)
+		if msg := checkFlight(f); msg != "" {
+			return api.StoredItinerary{}, map[string]any{errorKey: msg, indexKey: i}
+		}
+		if errBody := h.checkAirports(f); errBody != nil {
+			errBody[indexKey] = i
+			return api.StoredItinerary{}, errBody
+		}
+		next.Segments[i] = f
+	}
+
+	next.Options.Anchor = h.airports.Canonical(next.Options.Anchor)
+	itinerary, err := solveItinerary(next.Segments, next.Options.Mode, next.Options.Anchor)
+	if err != nil {
+		return api.StoredItinerary{}, itineraryErrorBody(err)
+	}
+	next.Start = itinerary.Path[0]
+	next.End = itinerary.Path[len(itinerary.Path)-1]
+	next.Path = itinerary.Path
+	return next, nil
+}
+
+// sameComputedFields reports whether a and b agree on every field the server
+// sets rather than the client: the ID, the version stamps and the solved path.
+func sameComputedFields(a, b api.StoredItinerary) bool {
+	return a.ID == b.ID && a.Created.Equal(b.Created) && a.Version == b.Version &&
+		a.Modified.Equal(b.Modified) && a.Start == b.Start && a.End == b.End && slices.Equal(a.Path, b.Path)
+}
+
+// applyJSONPatch applies an RFC 6902 JSON Patch document to doc.
+func applyJSONPatch(doc, patch []byte) ([]byte, error) {
+	p, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	return p.Apply(doc)
+}
+
+// etag returns the entity tag of an itinerary version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagMatches reports whether the If-Match header value names version: one
+// of its comma-separated tags is * or the version's strong tag. Weak tags
+// never match, as RFC 9110 requires for If-Match.
+func etagMatches(ifMatch string, version int) bool {
+	want := etag(version)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == want {
+			return true
+		}
+	}
+	return false
+}
+
+// ItineraryList godoc
+// @Summary List saved itineraries.
+// @Description return one page of saved itineraries in the order they were saved, with the total count. limit defaults to 20 and is capped at 100.
+// @Tags Itineraries
+// @ID itineraryList-get
+// @Produce json
+// @Param   offset	query	int	false	"Number of itineraries to skip (default 0)"
+// @Param   limit	query	int	false	"Page size (default 20, max 100)"
+// @Success 200 {object} api.ItineraryPage
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /itineraries [get].
+func (h Handler) ItineraryList(c *echo.Context) error {
+	offset, okOffset := pageParam(c.QueryParam(offsetParam), 0)
+	limit, okLimit := pageParam(c.QueryParam(limitParam), defaultPageLimit)
+	if !okOffset || !okLimit {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			errorKey: "offset and limit must be non-negative integers",
+		})
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	limit = min(limit, maxPageLimit)
+
+	page, total, err := h.itineraries.List(offset, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't list the itineraries"})
+	}
+	return c.JSON(http.StatusOK, api.ItineraryPage{Itineraries: page, Offset: offset, Limit: limit, Total: total})
+}
+
+// pageParam parses a non-negative pagination parameter, returning fallback
+// when raw is empty.
+func pageParam(raw string, fallback int) (int, bool) {
+	if raw == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(raw)
+	return n, err == nil && n >= 0
+}
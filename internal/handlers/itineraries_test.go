@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestItinerarySaveAndGet(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+
+	rec := serveRequest(t, h.ItinerarySave, http.MethodPost, "/itineraries?anchor=A", "", `[["A","B"],["b","a"]]`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("save status = %d, want 201, body = %s", rec.Code, rec.Body.String())
+	}
+	var saved api.StoredItinerary
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil {
+		t.Fatalf("failed to unmarshal itinerary: %v", err)
+	}
+	if saved.ID == "" || rec.Header().Get("Location") != "/itineraries/"+saved.ID {
+		t.Errorf("ID = %q, Location = %q", saved.ID, rec.Header().Get("Location"))
+	}
+	if !slices.Equal(saved.Path, []string{"A", "B", "A"}) || saved.Segments[1].Start != "B" || saved.Options.Anchor != "A" {
+		t.Errorf("saved = %+v, want normalized segments, anchor A and path A -> B -> A", saved)
+	}
+
+	rec = serveRequest(t, h.ItineraryGet, http.MethodGet, "/itineraries/"+saved.ID, saved.ID, "")
+	var got api.StoredItinerary
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK || got.ID != saved.ID {
+		t.Errorf("get = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serveRequest(t, h.ItinerarySave, http.MethodPost, "/itineraries", "", `[["A","B"],["C","D"]]`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid itinerary status = %d, want 400", rec.Code)
+	}
+	if _, total, _ := h.itineraries.List(0, 10); total != 1 {
+		t.Errorf("stored %d itineraries, want only the valid one", total)
+	}
+	rec = serveRequest(t, h.ItineraryGet, http.MethodGet, "/itineraries/404", "404", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown ID status = %d, want 404", rec.Code)
+	}
+}
+
+func TestItineraryList(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+	for range 25 {
+		serveRequest(t, h.ItinerarySave, http.MethodPost, "/itineraries", "", `[["SFO","EWR"]]`)
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantIDs    []string
+		wantLimit  int
+	}{
+		{target: "/itineraries", wantStatus: http.StatusOK, wantLimit: 20},
+		{target: "/itineraries?offset=22&limit=5", wantStatus: http.StatusOK, wantIDs: []string{"23", "24", "25"}, wantLimit: 5},
+		{target: "/itineraries?limit=1000", wantStatus: http.StatusOK, wantLimit: 100},
+		{target: "/itineraries?offset=-1", wantStatus: http.StatusBadRequest},
+		{target: "/itineraries?limit=ten", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := serveRequest(t, h.ItineraryList, http.MethodGet, tt.target, "", "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var page api.ItineraryPage
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("failed to unmarshal page: %v", err)
+			}
+			if page.Total != 25 || page.Limit != tt.wantLimit {
+				t.Errorf("Total/Limit = %d/%d, want 25/%d", page.Total, page.Limit, tt.wantLimit)
+			}
+			if tt.wantIDs != nil {
+				ids := make([]string, 0, len(page.Itineraries))
+				for _, it := range page.Itineraries {
+					ids = append(ids, it.ID)
+				}
+				if !slices.Equal(ids, tt.wantIDs) {
+					t.Errorf("IDs = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestItineraryPatch(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+	rec := serveRequest(t, h.ItinerarySave, http.MethodPost, "/itineraries", "", `[["SFO","ATL"],["ATL","EWR"]]`)
+	if rec.Code != http.StatusCreated || rec.Header().Get("ETag") != `"1"` {
+		t.Fatalf("save status = %d, ETag = %q, body = %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		wantStatus  int
+		wantETag    string
+		wantPath    []string
+	}{
+		{name: "no If-Match", contentType: jsonPatchType, body: `[]`, wantStatus: http.StatusPreconditionRequired},
+		{name: "plain JSON", contentType: "application/json", ifMatch: `"1"`, body: `[]`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "stale version", contentType: jsonPatchType, ifMatch: `"7"`, body: `[]`, wantStatus: http.StatusPreconditionFailed, wantETag: `"1"`},
+		{
+			name: "add segment", contentType: jsonPatchType, ifMatch: `"1"`,
+			body:       `[{"op":"add","path":"/Segments/-","value":{"Start":"ewr","End":"BOS"}}]`,
+			wantStatus: http.StatusOK, wantETag: `"2"`, wantPath: []string{"SFO", "ATL", "EWR", "BOS"},
+		},
+		{
+			name: "disconnected", contentType: mergePatchType, ifMatch: `"2"`,
+			body:       `{"Segments":[{"Start":"SFO","End":"ATL"},{"Start":"JFK","End":"BOS"}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{name: "computed field", contentType: mergePatchType, ifMatch: `"2"`, body: `{"Start":"LAX"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", contentType: mergePatchType, ifMatch: `"2"`, body: `{"Gate":"B2"}`, wantStatus: http.StatusBadRequest},
+		{name: "cabin option", contentType: mergePatchType, ifMatch: `"2"`, body: `{"Options":{"cabin":"first"}}`, wantStatus: http.StatusBadRequest},
+		{name: "sources option", contentType: jsonPatchType, ifMatch: `"2"`, body: `[{"op":"add","path":"/Options/sources","value":["bogus"]}]`, wantStatus: http.StatusBadRequest},
+		{name: "self loop", contentType: jsonPatchType, ifMatch: "*", body: `[{"op":"replace","path":"/Segments/0/End","value":"SFO"}]`, wantStatus: http.StatusBadRequest},
+		{name: "missing path", contentType: jsonPatchType, ifMatch: "*", body: `[{"op":"remove","path":"/Segments/9"}]`, wantStatus: http.StatusBadRequest},
+		{
+			name: "remove segment", contentType: jsonPatchType + "; charset=utf-8", ifMatch: `W/"2", "2"`,
+			body:       `[{"op":"remove","path":"/Segments/0"}]`,
+			wantStatus: http.StatusOK, wantETag: `"3"`, wantPath: []string{"ATL", "EWR", "BOS"},
+		},
+		{name: "weak tag", contentType: mergePatchType, ifMatch: `W/"3"`, body: `{}`, wantStatus: http.StatusPreconditionFailed, wantETag: `"3"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := servePatch(t, h, "1", tt.contentType, tt.ifMatch, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var it api.StoredItinerary
+			if err := json.Unmarshal(rec.Body.Bytes(), &it); err != nil {
+				t.Fatalf("failed to unmarshal itinerary: %v", err)
+			}
+			if !slices.Equal(it.Path, tt.wantPath) || it.Start != tt.wantPath[0] || it.End != tt.wantPath[len(tt.wantPath)-1] {
+				t.Errorf("patched = %+v, want path %v", it, tt.wantPath)
+			}
+		})
+	}
+
+	rec = serveRequest(t, h.ItineraryVersions, http.MethodGet, "/itineraries/1/versions", "1", "")
+	var history api.ItineraryVersions
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to unmarshal versions: %v", err)
+	}
+	if len(history.Versions) != 3 {
+		t.Fatalf("versions = %+v, want 3", history.Versions)
+	}
+	for i, v := range history.Versions {
+		if v.Version != i+1 || v.ID != "1" {
+			t.Errorf("version %d = %+v", i, v)
+		}
+	}
+	if first := history.Versions[0]; !slices.Equal(first.Path, []string{"SFO", "ATL", "EWR"}) {
+		t.Errorf("first version path = %v, want it unchanged", first.Path)
+	}
+	if rec := serveRequest(t, h.ItineraryGet, http.MethodGet, "/itineraries/1", "1", ""); rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("GET ETag = %q, want \"3\"", rec.Header().Get("ETag"))
+	}
+
+	if rec := servePatch(t, h, "9", mergePatchType, "*", `{}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown itinerary patch status = %d, want 404", rec.Code)
+	}
+	if rec := serveRequest(t, h.ItineraryVersions, http.MethodGet, "/itineraries/9/versions", "9", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown itinerary versions status = %d, want 404", rec.Code)
+	}
+}
+
+func TestItineraryPatchAnchor(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+	rec := serveRequest(t, h.ItinerarySave, http.MethodPost, "/itineraries?anchor=ksfo", "", `[["sfo","JFK"],["jfk","SFO"]]`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("save status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = servePatch(t, h, "1", mergePatchType, "*", `{"Options":{"anchor":"jfk"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var it api.StoredItinerary
+	if err := json.Unmarshal(rec.Body.Bytes(), &it); err != nil {
+		t.Fatalf("failed to unmarshal itinerary: %v", err)
+	}
+	if it.Options.Anchor != "JFK" || !slices.Equal(it.Path, []string{"JFK", "SFO", "JFK"}) {
+		t.Errorf("patched = %+v, want anchor JFK and path JFK -> SFO -> JFK", it)
+	}
+}
+
+// servePatch runs ItineraryPatch on the itinerary with the given ID, sending
+// body with the given Content-Type and If-Match headers.
+func servePatch(t *testing.T, h Handler, id, contentType, ifMatch, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPatch, "/itineraries/"+id, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetPathValues(echo.PathValues{{Name: "id", Value: id}})
+	if err := h.ItineraryPatch(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	return rec
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/jobs"
+	"github.com/AndriyKalashnykov/flight-path/internal/webhook"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// callbackParam is the query parameter naming the URL that a job is POSTed
+// to once it finishes.
+const callbackParam = "callback"
+
+// JobSubmit godoc
+// @Summary Submit a calculation to run asynchronously.
+// @Description validate the flight segments, queue them for the solver and return the new job at once with its ID; poll GET /jobs/{id} for the result, or pass a callback URL to have the finished job POSTed there with an HMAC-SHA256 X-Signature-256 header and exponential-backoff retries. The body accepts the same shapes as POST /calculate, and the anchor and mode query parameters apply as they do there. Finished jobs are kept until their Expires time (JOB_TTL after finishing).
+// @Tags Jobs
+// @ID jobSubmit-post
+// @Accept json
+// @Produce json
+// @Param   flightSegments	body	api.CalculateRequest	true	"Flight segments: a CalculateRequest object, or the legacy [][]string array"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Param   callback	query	string	false	"public http(s) URL the finished job is POSTed to (requires WEBHOOK_SECRET; private hosts need WEBHOOK_ALLOWED_HOSTS)"
+// @Success 202 {object} api.Job
+// @Header  202 {string} Location "/jobs/{id}"
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 503 {object} map[string]interface{}	"Job queue is full"
+// @Router /jobs [post].
+func (h Handler) JobSubmit(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	return h.submitJob(c, func(ctx context.Context, job *api.Job) {
+		result := solveResult(ctx, calc.flights, calc.options)
+		job.Result = &result
+	})
+}
+
+// submitJob queues task with the request's callback URL and answers 202 with
+// the new job and its Location.
+func (h Handler) submitJob(c *echo.Context, task jobs.Task) error {
+	job, err := h.jobs.Submit(task, c.QueryParam(callbackParam))
+	switch {
+	case errors.Is(err, webhook.ErrInvalidURL):
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: "Callback must be an absolute http or https URL"})
+	case errors.Is(err, webhook.ErrForbiddenHost):
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: "Callback host is not allowed: it is a private or loopback address not in WEBHOOK_ALLOWED_HOSTS"})
+	case errors.Is(err, jobs.ErrCallbacksDisabled):
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: "Callbacks are disabled: WEBHOOK_SECRET is not set"})
+	case err != nil:
+		return c.JSON(http.StatusServiceUnavailable, map[string]any{errorKey: "Job queue is full"})
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/jobs/"+job.ID)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// JobGet godoc
+// @Summary Get the status and result of an asynchronous job.
+// @Description return the job's status (queued, running, succeeded, failed or canceled), once it has finished its result, and for a job with a callback every delivery attempt. Unknown and expired job IDs return 404.
+// @Tags Jobs
+// @ID jobGet-get
+// @Produce json
+// @Param   id	path	string	true	"Job ID"
+// @Success 200 {object} api.Job
+// @Failure 404 {object} map[string]interface{}	"Job not found"
+// @Router /jobs/{id} [get].
+func (h Handler) JobGet(c *echo.Context) error {
+	job, err := h.jobs.Get(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Job not found"})
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// JobCancel godoc
+// @Summary Cancel an asynchronous job.
+// @Description cancel a queued or running job and return it with status canceled. A queued job never runs; a running job stops before its next solve (a batch stops between entries), while a solve already under way runs to completion and its result is discarded. A job that has already finished cannot be canceled and returns 409 with its current state.
+// @Tags Jobs
+// @ID jobCancel-delete
+// @Produce json
+// @Param   id	path	string	true	"Job ID"
+// @Success 200 {object} api.Job
+// @Failure 404 {object} map[string]interface{}	"Job not found"
+// @Failure 409 {object} map[string]interface{}	"Job already finished"
+// @Router /jobs/{id} [delete].
+func (h Handler) JobCancel(c *echo.Context) error {
+	job, err := h.jobs.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrFinished):
+		return c.JSON(http.StatusConflict, map[string]any{errorKey: "Job already finished", jobKey: job})
+	case err != nil:
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Job not found"})
+	}
+	return c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/jobs"
+	"github.com/AndriyKalashnykov/flight-path/internal/webhook"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// serveRequest runs handler for method target with an optional JSON body and
+// "id" path parameter, returning the recorded response.
+func serveRequest(t *testing.T, handler echo.HandlerFunc, method, target, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequestWithContext(context.Background(), method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if id != "" {
+		c.SetPathValues(echo.PathValues{{Name: "id", Value: id}})
+	}
+	if err := handler(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	return rec
+}
+
+func TestJobLifecycle(t *testing.T) {
+	h := New(WithJobs(jobs.New(1, 10, time.Minute, nil)))
+
+	rec := serveRequest(t, h.JobSubmit, http.MethodPost, "/jobs?anchor=A", "", `[["A","B"],["B","A"]]`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("submit status = %d, want 202, body = %s", rec.Code, rec.Body.String())
+	}
+	var job api.Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatalf("failed to unmarshal job: %v", err)
+	}
+	if loc := rec.Header().Get(echo.HeaderLocation); loc != "/jobs/"+job.ID {
+		t.Errorf("Location = %q, want /jobs/%s", loc, job.ID)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status == jobs.StatusQueued || job.Status == jobs.StatusRunning {
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		rec = serveRequest(t, h.JobGet, http.MethodGet, "/jobs/"+job.ID, job.ID, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get status = %d, want 200", rec.Code)
+		}
+		job = api.Job{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatalf("failed to unmarshal job: %v", err)
+		}
+	}
+	if job.Status != jobs.StatusSucceeded || job.Result == nil || !slices.Equal(job.Result.Path, []string{"A", "B", "A"}) {
+		t.Errorf("finished job = %+v, want succeeded A -> B -> A", job)
+	}
+
+	rec = serveRequest(t, h.JobCancel, http.MethodDelete, "/jobs/"+job.ID, job.ID, "")
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), `"Job":{"ID":"`+job.ID) {
+		t.Errorf("cancel finished job = %d %s, want 409 with the job", rec.Code, rec.Body.String())
+	}
+}
+
+func TestJobErrors(t *testing.T) {
+	h := New()
+	hooked := New(WithJobs(jobs.New(1, 1, time.Minute, webhook.New("secret", 1, time.Millisecond, nil))))
+	tests := []struct {
+		name       string
+		handler    echo.HandlerFunc
+		method     string
+		target     string
+		id         string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "invalid payload is rejected before queueing",
+			handler:    h.JobSubmit,
+			method:     http.MethodPost,
+			body:       `[["SFO"]]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Each flight segment must contain both source and destination",
+		},
+		{
+			name:       "callback without a webhook secret",
+			handler:    h.JobSubmit,
+			method:     http.MethodPost,
+			target:     "/jobs?callback=http://localhost:9/hook",
+			body:       `[["SFO","EWR"]]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Callbacks are disabled: WEBHOOK_SECRET is not set",
+		},
+		{
+			name:       "callback must be an http URL",
+			handler:    hooked.JobSubmit,
+			method:     http.MethodPost,
+			target:     "/jobs?callback=ftp://localhost/hook",
+			body:       `[["SFO","EWR"]]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Callback must be an absolute http or https URL",
+		},
+		{
+			name:       "callback to a metadata address",
+			handler:    hooked.JobSubmit,
+			method:     http.MethodPost,
+			target:     "/jobs?callback=http://169.254.169.254/latest/meta-data",
+			body:       `[["SFO","EWR"]]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Callback host is not allowed: it is a private or loopback address not in WEBHOOK_ALLOWED_HOSTS",
+		},
+		{
+			name:       "batch callback is validated too",
+			handler:    hooked.FlightBatch,
+			method:     http.MethodPost,
+			target:     "/calculate/batch?callback=hook",
+			body:       `{"p1": [["SFO","EWR"]]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Callback must be an absolute http or https URL",
+		},
+		{
+			name:       "unknown job",
+			handler:    h.JobGet,
+			method:     http.MethodGet,
+			id:         "missing",
+			wantStatus: http.StatusNotFound,
+			wantError:  "Job not found",
+		},
+		{
+			name:       "cancel unknown job",
+			handler:    h.JobCancel,
+			method:     http.MethodDelete,
+			id:         "missing",
+			wantStatus: http.StatusNotFound,
+			wantError:  "Job not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/jobs"
+			}
+			rec := serveRequest(t, tt.handler, tt.method, target, tt.id, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if body[errorKey] != tt.wantError {
+				t.Errorf("Error = %v, want %q", body[errorKey], tt.wantError)
+			}
+		})
+	}
+}
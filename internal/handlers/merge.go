@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// legKey identifies the leg a record describes. Records with a flight number
+// and a departure are keyed by the number, upper-cased without spaces, the
+// local departure date at the origin (see airports.Registry.LocalDate) and
+// the origin, which keeps the legs of a through flight apart; the same
+// instant written in different offsets gives the same date. Other records
+// are keyed by origin, destination and, when known, departure date.
+type legKey struct {
+	number string
+	date   string
+	start  string
+	end    string
+}
+
+// MergeSources de-duplicates records that several sources reported for the
+// same leg, keyed as legKey describes. A record without a flight number or
+// departure joins the flight-numbered leg with the same airports (and date,
+// when it has one) if there is exactly one. Within each leg the record from
+// the source listed first in priority wins, unlisted sources ranking after
+// listed ones and ties going to input order. Each other record is then
+// merged into it, filling a missing flight number or times, when every
+// field both set agrees, and discarded otherwise. Merged legs are returned
+// in order of their first record, with one api.Merge for every leg that
+// combined more than one record. Departure dates are taken in the origin's
+// time zone from reg.
+// Time complexity: O(n log n); space complexity: O(n).
+func MergeSources(records []api.Flight, priority []string, reg *airports.Registry) ([]api.Flight, []api.Merge) {
+	keys := legKeys(records, reg)
+	order := make([]legKey, 0, len(records))
+	groups := make(map[legKey][]int, len(records))
+	for i, k := range keys {
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+
+	rank := sourceRanks(priority)
+	rankOf := func(i int) int {
+		if r, ok := rank[records[i].Source]; ok {
+			return r
+		}
+		return len(priority)
+	}
+
+	merged := make([]api.Flight, 0, len(order))
+	var merges []api.Merge
+	for _, k := range order {
+		indexes := slices.Clone(groups[k])
+		slices.SortStableFunc(indexes, func(a, b int) int { return cmp.Compare(rankOf(a), rankOf(b)) })
+		kept := records[indexes[0]]
+		m := api.Merge{Segment: len(merged), Kept: indexes[0], Source: kept.Source}
+		for _, i := range indexes[1:] {
+			if conflicting(kept, records[i]) {
+				m.Discarded = append(m.Discarded, i)
+				continue
+			}
+			kept = fillFlight(kept, records[i])
+			m.Merged = append(m.Merged, i)
+		}
+		if len(indexes) > 1 {
+			slices.Sort(m.Merged)
+			slices.Sort(m.Discarded)
+			merges = append(merges, m)
+		}
+		merged = append(merged, kept)
+	}
+	return merged, merges
+}
+
+// legKeys returns the legKey of every record, first keying the records
+// with a flight number and a departure and then matching the others against
+// them.
+func legKeys(records []api.Flight, reg *airports.Registry) []legKey {
+	keys := make([]legKey, len(records))
+	byLeg := make(map[legKey][]legKey)
+	for i, f := range records {
+		if number := flightNumber(f.Number); number != "" && !f.Departure.IsZero() {
+			keys[i] = legKey{number: number, date: reg.LocalDate(f.Start, f.Departure), start: f.Start}
+			leg := legKey{start: f.Start, end: f.End}
+			if !slices.Contains(byLeg[leg], keys[i]) {
+				byLeg[leg] = append(byLeg[leg], keys[i])
+			}
+		}
+	}
+	for i, f := range records {
+		if keys[i] != (legKey{}) {
+			continue
+		}
+		keys[i] = legKey{start: f.Start, end: f.End}
+		if !f.Departure.IsZero() {
+			keys[i].date = reg.LocalDate(f.Start, f.Departure)
+		}
+		var match []legKey
+		for _, k := range byLeg[legKey{start: f.Start, end: f.End}] {
+			if keys[i].date == "" || k.date == keys[i].date {
+				match = append(match, k)
+			}
+		}
+		if len(match) == 1 {
+			keys[i] = match[0]
+		}
+	}
+	return keys
+}
+
+// sourceRanks maps each source in priority to its first position.
+func sourceRanks(priority []string) map[string]int {
+	rank := make(map[string]int, len(priority))
+	for i, source := range priority {
+		if _, ok := rank[source]; !ok {
+			rank[source] = i
+		}
+	}
+	return rank
+}
+
+// sourced reports whether any record is tagged with a source, which is what
+// opts a request into MergeSources.
+func sourced(flights []api.Flight) bool {
+	return slices.ContainsFunc(flights, func(f api.Flight) bool { return f.Source != "" })
+}
+
+// flightNumber returns a flight number upper-cased without spaces, so "dl 1"
+// and "DL1" match.
+func flightNumber(n string) string {
+	return strings.ToUpper(strings.ReplaceAll(n, " ", ""))
+}
+
+// conflicting reports whether a and b disagree on a field that both set.
+func conflicting(a, b api.Flight) bool {
+	na, nb := flightNumber(a.Number), flightNumber(b.Number)
+	return a.Start != b.Start || a.End != b.End ||
+		na != "" && nb != "" && na != nb ||
+		!a.Departure.IsZero() && !b.Departure.IsZero() && !a.Departure.Equal(b.Departure) ||
+		!a.Arrival.IsZero() && !b.Arrival.IsZero() && !a.Arrival.Equal(b.Arrival)
+}
+
+// fillFlight returns kept with its missing flight number and times taken
+// from other.
+func fillFlight(kept, other api.Flight) api.Flight {
+	if kept.Number == "" {
+		kept.Number = other.Number
+	}
+	if kept.Departure.IsZero() {
+		kept.Departure = other.Departure
+	}
+	if kept.Arrival.IsZero() {
+		kept.Arrival = other.Arrival
+	}
+	return kept
+}
+
+// splitList splits a comma-separated list, trimming spaces and dropping empty
+// entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if v := strings.TrimSpace(part); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
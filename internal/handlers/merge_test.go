@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestMergeSources(t *testing.T) {
+	dep := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	arr := dep.Add(5 * time.Hour)
+	// BA112 leaves JFK at 20:30 local on 1 March, which is already 2 March
+	// in UTC.
+	ba112 := time.Date(2026, 3, 2, 1, 30, 0, 0, time.UTC)
+	newYork := time.FixedZone("", -5*60*60)
+	tests := []struct {
+		name       string
+		records    []api.Flight
+		priority   []string
+		want       []api.Flight
+		wantMerges []api.Merge
+	}{
+		{
+			name: "no duplicates",
+			records: []api.Flight{
+				{Start: "SFO", End: "ATL", Source: "airline"},
+				{Start: "ATL", End: "EWR", Source: "agency"},
+			},
+			want: []api.Flight{
+				{Start: "SFO", End: "ATL", Source: "airline"},
+				{Start: "ATL", End: "EWR", Source: "agency"},
+			},
+		},
+		{
+			name: "same flight and date, gaps filled by lower priority",
+			records: []api.Flight{
+				{Start: "SFO", End: "ATL", Number: "dl 1", Departure: dep, Source: "agency"},
+				{Start: "ATL", End: "EWR", Source: "expense"},
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep, Arrival: arr, Source: "airline"},
+			},
+			priority: []string{"airline", "agency"},
+			want: []api.Flight{
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep, Arrival: arr, Source: "airline"},
+				{Start: "ATL", End: "EWR", Source: "expense"},
+			},
+			wantMerges: []api.Merge{{Segment: 0, Kept: 2, Source: "airline", Merged: []int{0}}},
+		},
+		{
+			name: "conflicting destination discarded",
+			records: []api.Flight{
+				{Start: "SFO", End: "ORD", Number: "UA5", Departure: dep, Source: "expense"},
+				{Start: "SFO", End: "ATL", Number: "UA5", Departure: dep.Add(time.Hour), Source: "airline"},
+			},
+			priority: []string{"airline", "agency", "expense"},
+			want: []api.Flight{
+				{Start: "SFO", End: "ATL", Number: "UA5", Departure: dep.Add(time.Hour), Source: "airline"},
+			},
+			wantMerges: []api.Merge{{Segment: 0, Kept: 1, Source: "airline", Discarded: []int{0}}},
+		},
+		{
+			name: "untimed record joins the numbered leg",
+			records: []api.Flight{
+				{Start: "SFO", End: "ATL", Source: "expense"},
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep, Source: "airline"},
+				{Start: "SFO", End: "ATL", Source: "agency"},
+			},
+			priority: []string{"airline"},
+			want: []api.Flight{
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep, Source: "airline"},
+			},
+			wantMerges: []api.Merge{{Segment: 0, Kept: 1, Source: "airline", Merged: []int{0, 2}}},
+		},
+		{
+			name: "ambiguous untimed record stays apart",
+			records: []api.Flight{
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep, Source: "airline"},
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep.AddDate(0, 0, 7), Source: "airline"},
+				{Start: "SFO", End: "ATL", Source: "expense"},
+			},
+			want: []api.Flight{
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep, Source: "airline"},
+				{Start: "SFO", End: "ATL", Number: "DL1", Departure: dep.AddDate(0, 0, 7), Source: "airline"},
+				{Start: "SFO", End: "ATL", Source: "expense"},
+			},
+		},
+		{
+			name: "through flight legs kept apart",
+			records: []api.Flight{
+				{Start: "SFO", End: "DEN", Number: "WN9", Departure: dep, Source: "airline"},
+				{Start: "DEN", End: "ATL", Number: "WN9", Departure: dep.Add(3 * time.Hour), Source: "airline"},
+			},
+			want: []api.Flight{
+				{Start: "SFO", End: "DEN", Number: "WN9", Departure: dep, Source: "airline"},
+				{Start: "DEN", End: "ATL", Number: "WN9", Departure: dep.Add(3 * time.Hour), Source: "airline"},
+			},
+		},
+		{
+			name: "same departure in different offsets merged",
+			records: []api.Flight{
+				{Start: "JFK", End: "LHR", Number: "BA112", Departure: ba112.In(newYork), Source: "airline"},
+				{Start: "JFK", End: "LHR", Number: "BA 112", Departure: ba112, Arrival: ba112.Add(7 * time.Hour), Source: "expense"},
+			},
+			priority: []string{"airline", "expense"},
+			want: []api.Flight{
+				{Start: "JFK", End: "LHR", Number: "BA112", Departure: ba112.In(newYork), Arrival: ba112.Add(7 * time.Hour), Source: "airline"},
+			},
+			wantMerges: []api.Merge{{Segment: 0, Kept: 0, Source: "airline", Merged: []int{1}}},
+		},
+		{
+			name: "unlisted sources keep input order",
+			records: []api.Flight{
+				{Start: "SFO", End: "ATL", Source: "expense"},
+				{Start: "SFO", End: "ATL", Source: "agency"},
+			},
+			priority: []string{"airline"},
+			want:     []api.Flight{{Start: "SFO", End: "ATL", Source: "expense"}},
+			wantMerges: []api.Merge{
+				{Segment: 0, Kept: 0, Source: "expense", Merged: []int{1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, merges := MergeSources(tt.records, tt.priority, airports.Default())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeSources() flights = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(merges, tt.wantMerges) {
+				t.Errorf("MergeSources() merges = %+v, want %+v", merges, tt.wantMerges)
+			}
+		})
+	}
+}
+
+func TestFlightItineraryMergesSources(t *testing.T) {
+	body := `{"segments":[
+		{"from":"ATL","to":"EWR","flight":"DL2","departs":"2026-03-01T13:00:00Z","source":"expense"},
+		{"from":"SFO","to":"ATL","flight":"DL1","departs":"2026-03-01T06:00:00Z","source":"airline"},
+		{"from":"ATL","to":"JFK","flight":"DL2","departs":"2026-03-01T13:00:00Z","source":"agency"},
+		{"from":"SFO","to":"ATL","source":"expense"}
+	]}`
+	// The ATL legs share DL2 on the same date but disagree on the
+	// destination, so one is discarded; the untimed expense SFO -> ATL
+	// record agrees with DL1 and is merged into it.
+	keptExpense := api.Merge{Segment: 0, Kept: 0, Source: "expense", Discarded: []int{2}}
+	keptAgency := api.Merge{Segment: 0, Kept: 2, Source: "agency", Discarded: []int{0}}
+	tests := []struct {
+		name      string
+		handler   Handler
+		target    string
+		wantEnd   string
+		wantMerge api.Merge
+	}{
+		{name: "handler priority", handler: New(WithSourcePriority([]string{"airline", " agency", "expense"})), target: "/calculate/itinerary", wantEnd: "JFK", wantMerge: keptAgency},
+		{name: "query overrides", handler: New(WithSourcePriority([]string{"agency"})), target: "/calculate/itinerary?sources=airline,expense", wantEnd: "EWR", wantMerge: keptExpense},
+		{name: "no priority keeps first", handler: New(), target: "/calculate/itinerary", wantEnd: "EWR", wantMerge: keptExpense},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveRequest(t, tt.handler.FlightItinerary, http.MethodPost, tt.target, "", body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+			var got api.Itinerary
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal itinerary: %v", err)
+			}
+			if len(got.Legs) != 2 || got.Path[2] != tt.wantEnd {
+				t.Fatalf("itinerary = %+v, want SFO -> ATL -> %s", got, tt.wantEnd)
+			}
+			want := []api.Merge{tt.wantMerge, {Segment: 1, Kept: 1, Source: "airline", Merged: []int{3}}}
+			if !reflect.DeepEqual(got.Merges, want) {
+				t.Errorf("Merges = %+v, want %+v", got.Merges, want)
+			}
+		})
+	}
+
+	rec := serveRequest(t, New().FlightItinerary, http.MethodPost, "/calculate/itinerary", "", `[["SFO","ATL"],["SFO","ATL"]]`)
+	var got api.Itinerary
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Merges != nil {
+		t.Errorf("untagged segments = %s, want no merge report", rec.Body.String())
+	}
+
+	// The same departure written in two offsets is one leg, not a
+	// chronology conflict.
+	rec = serveRequest(t, New().FlightItinerary, http.MethodPost, "/calculate/itinerary", "", `{"segments":[
+		{"from":"JFK","to":"LHR","flight":"BA112","departs":"2026-03-01T20:30:00-05:00","source":"airline"},
+		{"from":"JFK","to":"LHR","flight":"BA112","departs":"2026-03-02T01:30:00Z","source":"expense"}
+	]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("offsets: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got.Legs) != 1 {
+		t.Errorf("offsets = %s, want one merged leg", rec.Body.String())
+	}
+}
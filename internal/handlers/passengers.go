@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// atParam is the query parameter naming the instant of a location query.
+const atParam = "at"
+
+// Location statuses, as reported in api.Location.Status.
+const (
+	locationInFlight    = "in_flight"
+	locationAtAirport   = "at_airport"
+	locationNotDeparted = "not_departed"
+	locationArrived     = "arrived"
+	locationUnknown     = "unknown"
+)
+
+// PassengerCreate godoc
+// @Summary Register a passenger.
+// @Description create a passenger with an optional name and no segments; append timestamped segments with POST /passengers/{id}/segments.
+// @Tags Passengers
+// @ID passengerCreate-post
+// @Accept json
+// @Produce json
+// @Param   passenger	body	api.PassengerRequest	false	"Passenger details"
+// @Success 201 {object} api.Passenger
+// @Header  201 {string} Location "/passengers/{id}"
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /passengers [post].
+func (h Handler) PassengerCreate(c *echo.Context) error {
+	var req api.PassengerRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			errorKey: "Can't parse the payload",
+		})
+	}
+
+	p, err := h.itineraries.CreatePassenger(api.Passenger{Name: req.Name, Segments: []api.Flight{}})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't save the passenger"})
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/passengers/"+p.ID)
+	return c.JSON(http.StatusCreated, p)
+}
+
+// PassengerGet godoc
+// @Summary Get a passenger.
+// @Description return the passenger and every segment appended so far, in the order they were appended.
+// @Tags Passengers
+// @ID passengerGet-get
+// @Produce json
+// @Param   id	path	string	true	"Passenger ID"
+// @Success 200 {object} api.Passenger
+// @Failure 404 {object} map[string]interface{}	"Passenger not found"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /passengers/{id} [get].
+func (h Handler) PassengerGet(c *echo.Context) error {
+	p, err := h.itineraries.Passenger(c.Param("id"))
+	if err != nil {
+		return passengerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// PassengerAppend godoc
+// @Summary Append flight segments to a passenger.
+// @Description add segments to the passenger's record. The body accepts the same shapes as POST /calculate, and every segment needs both a departure and an arrival time. The passenger's segments, old and new together, must still form one valid timed itinerary; otherwise nothing is appended and the chronology error is returned, with Indexes counting the existing segments first.
+// @Tags Passengers
+// @ID passengerAppend-post
+// @Accept json
+// @Produce json
+// @Param   id	path	string	true	"Passenger ID"
+// @Param   flightSegments	body	api.CalculateRequest	true	"Timestamped flight segments: a CalculateRequest object, or the legacy [][]string array of [source, destination, departure, arrival]"
+// @Success 200 {object} api.Passenger
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 404 {object} map[string]interface{}	"Passenger not found"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /passengers/{id}/segments [post].
+func (h Handler) PassengerAppend(c *echo.Context) error {
+	calc, errBody := h.bindCalculation(c)
+	if errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+	for i, f := range calc.flights {
+		if f.Departure.IsZero() || f.Arrival.IsZero() {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				errorKey: "Passenger segments need departure and arrival times",
+				indexKey: i,
+			})
+		}
+	}
+
+	p, err := h.itineraries.AppendSegments(c.Param("id"), calc.flights, func(all []api.Flight) error {
+		_, err := ReconstructTimedItinerary(all)
+		return err
+	})
+	if errors.Is(err, ErrChronology) {
+		return c.JSON(http.StatusBadRequest, itineraryErrorBody(err))
+	}
+	if err != nil {
+		return passengerErrorResponse(c, err)
+	}
+	h.contacts.Set(p.ID, p.Segments)
+	return c.JSON(http.StatusOK, p)
+}
+
+// PassengerLocate godoc
+// @Summary Find where a passenger was at a given time.
+// @Description reconstruct the passenger's itinerary from the timestamped segments and report whether, at the given instant, they were in flight (with the segment), at an airport between two legs, not yet departed from the origin, or arrived at the final destination.
+// @Tags Passengers
+// @ID passengerLocate-get
+// @Produce json
+// @Param   id	path	string	true	"Passenger ID"
+// @Param   at	query	string	true	"RFC 3339 instant, e.g. 2026-03-01T09:00:00Z"
+// @Success 200 {object} api.Location
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 404 {object} map[string]interface{}	"Passenger not found"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /passengers/{id}/location [get].
+func (h Handler) PassengerLocate(c *echo.Context) error {
+	at, err := time.Parse(time.RFC3339, c.QueryParam(atParam))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			errorKey: "The at parameter must be an RFC 3339 timestamp",
+		})
+	}
+
+	p, err := h.itineraries.Passenger(c.Param("id"))
+	if err != nil {
+		return passengerErrorResponse(c, err)
+	}
+	itinerary, err := ReconstructTimedItinerary(p.Segments)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Stored segments no longer form an itinerary"})
+	}
+
+	loc := LocateAt(itinerary, at)
+	loc.Passenger = p.ID
+	return c.JSON(http.StatusOK, loc)
+}
+
+// LocateAt reports where the traveller of a timed itinerary was at instant
+// at: on the leg whose [Departure, Arrival) span holds it, at the airport
+// between two legs, at the origin before the first departure, or at the final
+// destination after the last arrival. Legs must be in travel order with both
+// timestamps set, as ReconstructTimedItinerary returns them for passenger
+// segments. An empty itinerary yields the "unknown" status.
+// Time complexity: O(log n).
+func LocateAt(itinerary api.Itinerary, at time.Time) api.Location {
+	loc := api.Location{At: at, Status: locationUnknown}
+	legs := itinerary.Legs
+	if len(legs) == 0 {
+		return loc
+	}
+
+	// next is the first leg that departs after at.
+	next := sort.Search(len(legs), func(i int) bool { return legs[i].Departure.After(at) })
+	switch {
+	case next == 0:
+		loc.Status, loc.Airport = locationNotDeparted, legs[0].Start
+	case at.Before(legs[next-1].Arrival):
+		leg := legs[next-1]
+		loc.Status, loc.Segment = locationInFlight, &leg
+	case next == len(legs):
+		loc.Status, loc.Airport = locationArrived, legs[next-1].End
+	default:
+		loc.Status, loc.Airport = locationAtAirport, legs[next-1].End
+	}
+	return loc
+}
+
+// passengerErrorResponse answers a store error for a passenger lookup.
+func passengerErrorResponse(c *echo.Context, err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Passenger not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]any{errorKey: "Can't read the passenger"})
+}
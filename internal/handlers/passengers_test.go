@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/store"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestPassengerSegments(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+
+	rec := serveRequest(t, h.PassengerCreate, http.MethodPost, "/passengers", "", `{"name":"Alice"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201, body = %s", rec.Code, rec.Body.String())
+	}
+	var p api.Passenger
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("failed to unmarshal passenger: %v", err)
+	}
+	if p.Name != "Alice" || rec.Header().Get("Location") != "/passengers/"+p.ID {
+		t.Errorf("passenger = %+v, Location = %q", p, rec.Header().Get("Location"))
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantSegments int
+	}{
+		{name: "first leg", body: `[["SFO","ATL","2026-03-01T06:00:00Z","2026-03-01T11:00:00Z"]]`, wantStatus: http.StatusOK, wantSegments: 1},
+		{name: "connecting leg", body: `{"segments":[{"from":"ATL","to":"EWR","flight":"DL2","departs":"2026-03-01T13:00:00Z","arrives":"2026-03-01T15:00:00Z"}]}`, wantStatus: http.StatusOK, wantSegments: 2},
+		{name: "departs before landing", body: `[["EWR","BOS","2026-03-01T14:00:00Z","2026-03-01T15:00:00Z"]]`, wantStatus: http.StatusBadRequest, wantSegments: 2},
+		{name: "wrong airport", body: `[["JFK","BOS","2026-03-02T08:00:00Z","2026-03-02T09:00:00Z"]]`, wantStatus: http.StatusBadRequest, wantSegments: 2},
+		{name: "no arrival", body: `[["EWR","BOS","2026-03-02T08:00:00Z"]]`, wantStatus: http.StatusBadRequest, wantSegments: 2},
+		{name: "untimed", body: `[["EWR","BOS"]]`, wantStatus: http.StatusBadRequest, wantSegments: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveRequest(t, h.PassengerAppend, http.MethodPost, "/passengers/"+p.ID+"/segments", p.ID, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			rec = serveRequest(t, h.PassengerGet, http.MethodGet, "/passengers/"+p.ID, p.ID, "")
+			var got api.Passenger
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got.Segments) != tt.wantSegments {
+				t.Errorf("passenger has %d segments, want %d (%v)", len(got.Segments), tt.wantSegments, err)
+			}
+		})
+	}
+
+	if rec := serveRequest(t, h.PassengerGet, http.MethodGet, "/passengers/9", "9", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown passenger get status = %d, want 404", rec.Code)
+	}
+	rec = serveRequest(t, h.PassengerAppend, http.MethodPost, "/passengers/9/segments", "9", `[["A","B","2026-03-01T06:00:00Z","2026-03-01T07:00:00Z"]]`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown passenger append status = %d, want 404", rec.Code)
+	}
+	rec = serveRequest(t, h.PassengerLocate, http.MethodGet, "/passengers/9/location?at=2026-03-01T06:00:00Z", "9", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown passenger locate status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPassengerLocate(t *testing.T) {
+	h := New(WithStore(store.NewMemory()))
+	p, err := h.itineraries.CreatePassenger(api.Passenger{})
+	if err != nil {
+		t.Fatalf("CreatePassenger: %v", err)
+	}
+	serveRequest(t, h.PassengerAppend, http.MethodPost, "/passengers/"+p.ID+"/segments", p.ID,
+		`[["ATL","EWR","2026-03-01T13:00:00Z","2026-03-01T15:00:00Z"],["SFO","ATL","2026-03-01T06:00:00Z","2026-03-01T11:00:00Z"]]`)
+
+	tests := []struct {
+		at          string
+		wantStatus  string
+		wantAirport string
+		wantSegment string
+	}{
+		{at: "2026-03-01T05:00:00Z", wantStatus: locationNotDeparted, wantAirport: "SFO"},
+		{at: "2026-03-01T06:00:00Z", wantStatus: locationInFlight, wantSegment: "SFO"},
+		{at: "2026-03-01T10:59:59Z", wantStatus: locationInFlight, wantSegment: "SFO"},
+		{at: "2026-03-01T11:00:00Z", wantStatus: locationAtAirport, wantAirport: "ATL"},
+		{at: "2026-03-01T14:00:00Z", wantStatus: locationInFlight, wantSegment: "ATL"},
+		{at: "2026-03-01T15:00:00Z", wantStatus: locationArrived, wantAirport: "EWR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			rec := serveRequest(t, h.PassengerLocate, http.MethodGet, "/passengers/"+p.ID+"/location?at="+tt.at, p.ID, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200, body = %s", rec.Code, rec.Body.String())
+			}
+			var loc api.Location
+			if err := json.Unmarshal(rec.Body.Bytes(), &loc); err != nil {
+				t.Fatalf("failed to unmarshal location: %v", err)
+			}
+			segment := ""
+			if loc.Segment != nil {
+				segment = loc.Segment.Start
+			}
+			if loc.Passenger != p.ID || loc.Status != tt.wantStatus || loc.Airport != tt.wantAirport || segment != tt.wantSegment {
+				t.Errorf("location = %+v, want %s at %q on segment from %q", loc, tt.wantStatus, tt.wantAirport, tt.wantSegment)
+			}
+		})
+	}
+
+	for _, target := range []string{"/passengers/1/location", "/passengers/1/location?at=yesterday"} {
+		if rec := serveRequest(t, h.PassengerLocate, http.MethodGet, target, p.ID, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s status = %d, want 400", target, rec.Code)
+		}
+	}
+	if loc := LocateAt(api.Itinerary{}, at(t, "2026-03-01T05:00:00Z")); loc.Status != locationUnknown {
+		t.Errorf("LocateAt(empty) status = %q, want %q", loc.Status, locationUnknown)
+	}
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// requestVersion is the only CalculateRequest version understood so far.
+const requestVersion = 1
+
+// calculation is a bound and validated calculate request.
+type calculation struct {
+	flights  []api.Flight
+	options  api.Options
+	rewrites []api.Rewrite
+	merges   []api.Merge
+	// object reports whether the body used the api.CalculateRequest form,
+	// which is answered with an object response.
+	object bool
+}
+
+// bindCalculation binds the request body with decodeCalculation, layering
+// the mode, anchor, cabin and sources query parameters on top of the body
+// options. On failure it returns the 400 error body to send instead (with
+// Index set for per-segment errors); on success the body is nil.
+func (h Handler) bindCalculation(c *echo.Context) (calculation, map[string]any) {
+	var raw json.RawMessage
+
+	// bind payload
+	err := c.Bind(&raw)
+	if err != nil {
+		return calculation{}, map[string]any{
+			errorKey: "Can't parse the payload",
+		}
+	}
+
+	return h.decodeCalculation(raw, queryOptions(c))
+}
+
+// decodeCalculation decodes one set of flight segments, normalizes their
+// airport codes and validates each one (in strict mode the codes must also be
+// in the registry). raw is either the legacy [][]string array or an
+// api.CalculateRequest object, told apart by its first JSON token, and
+// override is applied on top of its options. When any segment is tagged with
+// a source the segments are then merged with MergeSources, ranking sources
+// by the Sources option or, without one, the handler's source priority. On
+// failure it returns the error body to send instead; on success the body is
+// nil.
+func (h Handler) decodeCalculation(raw []byte, override api.Options) (calculation, map[string]any) {
+	var req api.CalculateRequest
+	var errBody map[string]any
+	trimmed := bytes.TrimSpace(raw)
+	object := len(trimmed) > 0 && trimmed[0] == '{'
+	switch {
+	case len(trimmed) == 0:
+	case trimmed[0] == '[':
+		req.Segments, errBody = decodeLegacy(trimmed)
+	case object:
+		req, errBody = decodeObject(trimmed)
+	default:
+		errBody = map[string]any{errorKey: "Can't parse the payload"}
+	}
+	if errBody != nil {
+		return calculation{}, errBody
+	}
+
+	// validate payload
+	if len(req.Segments) == 0 {
+		return calculation{}, map[string]any{
+			errorKey: "Flight segments cannot be empty",
+		}
+	}
+
+	calc := calculation{
+		flights: make([]api.Flight, 0, len(req.Segments)),
+		options: req.Options,
+		object:  object,
+	}
+	for i, seg := range req.Segments {
+		seg = h.normalizeSegment(i, seg, &calc.rewrites)
+		f, msg := segmentFlight(seg)
+		if msg != "" {
+			return calculation{}, map[string]any{
+				errorKey: msg,
+				indexKey: i,
+			}
+		}
+		if errBody := h.checkAirports(f); errBody != nil {
+			errBody[indexKey] = i
+			return calculation{}, errBody
+		}
+		calc.flights = append(calc.flights, f)
+	}
+
+	calc.options = overrideOptions(calc.options, override)
+	// The anchor is matched against normalized segment codes, so it gets the
+	// same treatment.
+	calc.options.Anchor = h.airports.Canonical(calc.options.Anchor)
+	if sourced(calc.flights) {
+		priority := h.sourcePriority
+		if len(calc.options.Sources) > 0 {
+			priority = calc.options.Sources
+		}
+		calc.flights, calc.merges = MergeSources(calc.flights, priority, h.airports)
+	}
+	return calc, nil
+}
+
+// queryOptions returns the mode, anchor, cabin and sources query parameters.
+func queryOptions(c *echo.Context) api.Options {
+	return api.Options{
+		Mode:    c.QueryParam(modeParam),
+		Anchor:  c.QueryParam(anchorParam),
+		Cabin:   c.QueryParam(cabinParam),
+		Sources: splitList(c.QueryParam(sourcesParam)),
+	}
+}
+
+// overrideOptions returns base with every non-empty field of override
+// applied on top.
+func overrideOptions(base, override api.Options) api.Options {
+	if override.Mode != "" {
+		base.Mode = override.Mode
+	}
+	if override.Anchor != "" {
+		base.Anchor = override.Anchor
+	}
+	if override.Cabin != "" {
+		base.Cabin = override.Cabin
+	}
+	if len(override.Sources) > 0 {
+		base.Sources = override.Sources
+	}
+	return base
+}
+
+// normalizeSegment replaces the segment's airport codes with their canonical
+// form (see airports.Registry.Canonical), appending a Rewrite to rewrites for
+// every code that changed.
+func (h Handler) normalizeSegment(i int, seg api.Segment, rewrites *[]api.Rewrite) api.Segment {
+	if c := h.airports.Canonical(seg.From); c != seg.From {
+		*rewrites = append(*rewrites, api.Rewrite{Index: i, Field: "Start", From: seg.From, To: c})
+		seg.From = c
+	}
+	if c := h.airports.Canonical(seg.To); c != seg.To {
+		*rewrites = append(*rewrites, api.Rewrite{Index: i, Field: "End", From: seg.To, To: c})
+		seg.To = c
+	}
+	return seg
+}
+
+// checkAirports rejects, in strict mode, a segment whose airport codes are
+// not in the registry. It returns the 400 error body to send, or nil.
+func (h Handler) checkAirports(f api.Flight) map[string]any {
+	if !h.strictAirports {
+		return nil
+	}
+	for _, code := range []string{f.Start, f.End} {
+		if _, ok := h.airports.Lookup(code); !ok {
+			return map[string]any{
+				errorKey:   fmt.Sprintf("Unknown airport code %q", code),
+				airportKey: code,
+			}
+		}
+	}
+	return nil
+}
+
+// decodeLegacy converts the legacy [][]string body into segments. Elements
+// after the airport codes are read as departure and arrival only when they
+// parse as RFC 3339 timestamps; anything else is ignored, so legacy payloads
+// with extra non-time items keep working.
+func decodeLegacy(raw []byte) ([]api.Segment, map[string]any) {
+	var payload [][]string
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, map[string]any{
+			errorKey: "Can't parse the payload",
+		}
+	}
+	segments := make([]api.Segment, 0, len(payload))
+	for i, v := range payload {
+		if len(v) < 2 {
+			return nil, map[string]any{
+				errorKey: "Each flight segment must contain both source and destination",
+				indexKey: i,
+			}
+		}
+		seg := api.Segment{From: v[0], To: v[1]}
+		if len(v) > 2 && isTimestamp(v[2]) {
+			seg.Departs = v[2]
+		}
+		if len(v) > 3 && isTimestamp(v[3]) {
+			seg.Arrives = v[3]
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// decodeObject decodes an api.CalculateRequest body, rejecting unknown fields
+// so that a stray object is reported as a parse error rather than as an
+// empty request.
+func decodeObject(raw []byte) (api.CalculateRequest, map[string]any) {
+	var req api.CalculateRequest
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return api.CalculateRequest{}, map[string]any{
+			errorKey: "Can't parse the payload",
+		}
+	}
+	if req.Version != 0 && req.Version != requestVersion {
+		return api.CalculateRequest{}, map[string]any{
+			errorKey: fmt.Sprintf("Unsupported request version %d: want %d", req.Version, requestVersion),
+		}
+	}
+	return req, nil
+}
+
+// isTimestamp reports whether a legacy extra element is an RFC 3339
+// timestamp, which is what makes it a departure or arrival.
+func isTimestamp(s string) bool {
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
+
+// segmentFlight validates one segment and converts it to an api.Flight. On
+// failure it returns the validation message to send.
+func segmentFlight(seg api.Segment) (api.Flight, string) {
+	if msg := checkCodes(seg.From, seg.To); msg != "" {
+		return api.Flight{}, msg
+	}
+	departure, arrival, msg := parseSegmentTimes(seg.Departs, seg.Arrives)
+	if msg != "" {
+		return api.Flight{}, msg
+	}
+	return api.Flight{
+		Start:     seg.From,
+		End:       seg.To,
+		Number:    seg.Flight,
+		Departure: departure,
+		Arrival:   arrival,
+		Source:    seg.Source,
+	}, ""
+}
+
+// parseSegmentTimes parses a segment's optional RFC 3339 departure and
+// arrival; an empty string leaves the time zero. On failure it returns the
+// validation message to send.
+func parseSegmentTimes(departs, arrives string) (departure, arrival time.Time, msg string) {
+	var times [2]time.Time
+	for k, v := range []string{departs, arrives} {
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, "Departure and arrival times must be RFC 3339 timestamps"
+		}
+		times[k] = t
+	}
+	if msg := checkTimes(times[0], times[1]); msg != "" {
+		return time.Time{}, time.Time{}, msg
+	}
+	return times[0], times[1], ""
+}
+
+// checkFlight validates a segment that is already an api.Flight with the
+// rules segmentFlight applies to an api.Segment. On failure it returns the
+// validation message to send.
+func checkFlight(f api.Flight) string {
+	if msg := checkCodes(f.Start, f.End); msg != "" {
+		return msg
+	}
+	return checkTimes(f.Departure, f.Arrival)
+}
+
+// checkCodes validates a segment's airport codes.
+func checkCodes(from, to string) string {
+	if from == "" || to == "" {
+		return "Airport codes must be non-empty"
+	}
+	if from == to {
+		return "Source and destination airports must differ"
+	}
+	return ""
+}
+
+// checkTimes validates a segment's optional departure and arrival.
+func checkTimes(departure, arrival time.Time) string {
+	if !arrival.IsZero() && departure.IsZero() {
+		return "Arrival time requires a departure time"
+	}
+	if !arrival.IsZero() && !arrival.After(departure) {
+		return "Arrival time must be after departure time"
+	}
+	return ""
+}
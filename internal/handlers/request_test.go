@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestBindFlights(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantErr    string
+		wantIndex  int
+		wantStarts []string
+		wantNumber string
+		wantMode   string
+		wantAnchor string
+	}{
+		{
+			name:       "legacy array",
+			body:       `[["ATL","EWR"],["SFO","ATL"]]`,
+			wantStarts: []string{"ATL", "SFO"},
+		},
+		{
+			name:       "legacy numeric extra element ignored",
+			body:       `[["SFO","EWR","123"]]`,
+			wantStarts: []string{"SFO"},
+		},
+		{
+			name:       "legacy malformed time ignored",
+			body:       `[["SFO","EWR","2026-03-01 06:00"]]`,
+			wantStarts: []string{"SFO"},
+		},
+		{
+			name:       "object form with flight number and options",
+			body:       `{"version":1,"segments":[{"from":"SFO","to":"ATL","flight":"DL123","departs":"2026-03-01T06:00:00Z"}],"options":{"mode":"eulerian","anchor":"SFO"}}`,
+			wantStarts: []string{"SFO"},
+			wantNumber: "DL123",
+			wantMode:   "eulerian",
+			wantAnchor: "SFO",
+		},
+		{
+			name:       "query parameters override body options",
+			target:     "/calculate?mode=path",
+			body:       `{"segments":[{"from":"SFO","to":"ATL"}],"options":{"mode":"eulerian","anchor":"SFO"}}`,
+			wantStarts: []string{"SFO"},
+			wantMode:   "path",
+			wantAnchor: "SFO",
+		},
+		{
+			name:       "lower-case anchor is normalized",
+			target:     "/calculate?anchor=sfo",
+			body:       `[["sfo","JFK"],["jfk","SFO"]]`,
+			wantStarts: []string{"SFO", "JFK"},
+			wantAnchor: "SFO",
+		},
+		{
+			name:       "ICAO anchor is normalized",
+			body:       `{"segments":[{"from":"SFO","to":"JFK"}],"options":{"anchor":" KSFO"}}`,
+			wantStarts: []string{"SFO"},
+			wantAnchor: "SFO",
+		},
+		{
+			name:    "object form without segments",
+			body:    `{"segments":[]}`,
+			wantErr: "Flight segments cannot be empty",
+		},
+		{
+			name:    "unknown object field",
+			body:    `{"foo":"bar"}`,
+			wantErr: "Can't parse the payload",
+		},
+		{
+			name:    "unsupported version",
+			body:    `{"version":2,"segments":[{"from":"SFO","to":"ATL"}]}`,
+			wantErr: "Unsupported request version 2: want 1",
+		},
+		{
+			name:      "object segment with empty code",
+			body:      `{"segments":[{"from":"SFO","to":"ATL"},{"from":"ATL"}]}`,
+			wantErr:   "Airport codes must be non-empty",
+			wantIndex: 1,
+		},
+		{
+			name:      "object segment with malformed departure",
+			body:      `{"segments":[{"from":"SFO","to":"ATL","departs":"tomorrow"}]}`,
+			wantErr:   "Departure and arrival times must be RFC 3339 timestamps",
+			wantIndex: 0,
+		},
+		{
+			name:    "scalar body",
+			body:    `"SFO"`,
+			wantErr: "Can't parse the payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/calculate"
+			}
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			calc, errBody := New().bindCalculation(c)
+			if tt.wantErr != "" {
+				if errBody == nil || errBody[errorKey] != tt.wantErr {
+					t.Fatalf("error body = %v, want %q", errBody, tt.wantErr)
+				}
+				if idx, ok := errBody[indexKey]; ok && idx != tt.wantIndex {
+					t.Errorf("Index = %v, want %d", idx, tt.wantIndex)
+				}
+				return
+			}
+			if errBody != nil {
+				t.Fatalf("unexpected error body: %v", errBody)
+			}
+			starts := make([]string, 0, len(calc.flights))
+			for _, f := range calc.flights {
+				starts = append(starts, f.Start)
+			}
+			if !slices.Equal(starts, tt.wantStarts) {
+				t.Errorf("starts = %v, want %v", starts, tt.wantStarts)
+			}
+			if calc.flights[0].Number != tt.wantNumber {
+				t.Errorf("Number = %q, want %q", calc.flights[0].Number, tt.wantNumber)
+			}
+			if calc.options.Mode != tt.wantMode || calc.options.Anchor != tt.wantAnchor {
+				t.Errorf("options = %+v, want mode %q anchor %q", calc.options, tt.wantMode, tt.wantAnchor)
+			}
+		})
+	}
+}
+
+func TestFlightItineraryObjectBody(t *testing.T) {
+	body := `{"segments":[{"from":"ATL","to":"EWR","flight":"DL2"},{"from":"SFO","to":"ATL","flight":"DL1"}]}`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate/itinerary", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	if err := New().FlightItinerary(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		Path []string
+		Legs []struct{ Number string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !slices.Equal(got.Path, []string{"SFO", "ATL", "EWR"}) {
+		t.Errorf("path = %v", got.Path)
+	}
+	if len(got.Legs) != 2 || got.Legs[0].Number != "DL1" || got.Legs[1].Number != "DL2" {
+		t.Errorf("legs = %+v, want flight numbers DL1, DL2", got.Legs)
+	}
+}
+
+func TestBindFlightsStrictAirports(t *testing.T) {
+	tests := []struct {
+		name        string
+		strict      bool
+		body        string
+		wantAirport string
+		wantIndex   int
+	}{
+		{
+			name: "lenient accepts unknown codes",
+			body: `[["SFO","XXX"]]`,
+		},
+		{
+			name:   "strict accepts known codes",
+			strict: true,
+			body:   `[["SFO","ATL"],["ATL","EWR"]]`,
+		},
+		{
+			name:        "strict rejects an unknown destination",
+			strict:      true,
+			body:        `[["SFO","ATL"],["ATL","XXX"]]`,
+			wantAirport: "XXX",
+			wantIndex:   1,
+		},
+		{
+			name:   "strict accepts codes after normalization",
+			strict: true,
+			body:   `{"segments":[{"from":" sfo","to":"KATL"}]}`,
+		},
+		{
+			name:        "strict rejects unknown codes after normalization",
+			strict:      true,
+			body:        `{"segments":[{"from":"sfo","to":"zzz "}]}`,
+			wantAirport: "ZZZ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			_, errBody := New(WithStrictAirports(tt.strict)).bindCalculation(c)
+			if tt.wantAirport == "" {
+				if errBody != nil {
+					t.Fatalf("unexpected error body: %v", errBody)
+				}
+				return
+			}
+			if errBody == nil || errBody[airportKey] != tt.wantAirport || errBody[indexKey] != tt.wantIndex {
+				t.Errorf("error body = %v, want Airport %q at Index %d", errBody, tt.wantAirport, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestBindCalculationNormalizes(t *testing.T) {
+	body := `[[" sfo","KATL"],["atl","EWR"]]`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/json")
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	calc, errBody := New().bindCalculation(c)
+	if errBody != nil {
+		t.Fatalf("unexpected error body: %v", errBody)
+	}
+	wantFlights := []api.Flight{{Start: "SFO", End: "ATL"}, {Start: "ATL", End: "EWR"}}
+	if !slices.Equal(calc.flights, wantFlights) {
+		t.Errorf("flights = %v, want %v", calc.flights, wantFlights)
+	}
+	wantRewrites := []api.Rewrite{
+		{Index: 0, Field: "Start", From: " sfo", To: "SFO"},
+		{Index: 0, Field: "End", From: "KATL", To: "ATL"},
+		{Index: 1, Field: "Start", From: "atl", To: "ATL"},
+	}
+	if !slices.Equal(calc.rewrites, wantRewrites) {
+		t.Errorf("rewrites = %v, want %v", calc.rewrites, wantRewrites)
+	}
+}
+
+func TestFlightCalculateObjectResponse(t *testing.T) {
+	body := `{"segments":[{"from":"ATL","to":"kewr"},{"from":"KSFO","to":"ATL"}]}`
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	if err := New().FlightCalculate(c); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var got api.CalculateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if got.Start != "SFO" || got.End != "EWR" || len(got.Rewrites) != 2 {
+		t.Errorf("response = %+v, want SFO -> EWR with 2 rewrites", got)
+	}
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// Defaults for WithSessions used when no configuration is given.
+const (
+	DefaultSessionTTL         = 30 * time.Minute
+	DefaultMaxSessions        = 10000
+	DefaultMaxSessionSegments = 1000
+)
+
+// Session statuses, as reported in api.Session.Status.
+const (
+	sessionEmpty        = "empty"
+	sessionValid        = "valid"
+	sessionCircular     = "circular"
+	sessionDisconnected = "disconnected"
+)
+
+// itinerarySession keeps the state FindItinerary builds — the successor and
+// predecessor maps and the start and end candidate sets — together with a
+// union-find over the airports, updating all of it as each segment arrives
+// instead of recomputing it from the whole list.
+type itinerarySession struct {
+	expires time.Time // guarded by the registry's mu
+	timer   *time.Timer
+
+	mu         sync.Mutex
+	id         string
+	created    time.Time
+	segments   []api.Flight
+	known      map[string]api.Flight // each source's segments combined by fillFlight
+	next       map[string]string
+	prev       map[string]string
+	starts     map[string]struct{}
+	ends       map[string]struct{}
+	airports   *unionFind
+	components int
+}
+
+func newItinerarySession() *itinerarySession {
+	return &itinerarySession{
+		id:       rand.Text(),
+		created:  time.Now().UTC(),
+		known:    make(map[string]api.Flight),
+		next:     make(map[string]string),
+		prev:     make(map[string]string),
+		starts:   make(map[string]struct{}),
+		ends:     make(map[string]struct{}),
+		airports: newUnionFind(0),
+	}
+}
+
+// add appends f to the session. A segment that would give an airport a
+// second distinct successor or predecessor can never be part of a single
+// path, so it is rejected with a *BranchingError and the session is left
+// unchanged, as is a second flight on a route already in the session. A
+// duplicate of the same flight is accepted and, as in FindItinerary, behaves
+// as the segment already there.
+// Time complexity: O(α(n)) amortized.
+func (s *itinerarySession) add(f api.Flight) error {
+	if dst, ok := s.next[f.Start]; ok && (dst != f.End || conflicting(s.known[f.Start], f)) {
+		return newBranchingError(append(slices.Clip(s.segments), f), f.Start, true)
+	}
+	if src, ok := s.prev[f.End]; ok && src != f.Start {
+		return newBranchingError(append(slices.Clip(s.segments), f), f.End, false)
+	}
+	s.segments = append(s.segments, f)
+	s.known[f.Start] = fillFlight(f, s.known[f.Start])
+	if _, dup := s.next[f.Start]; dup {
+		return nil
+	}
+
+	for _, a := range [2]string{f.Start, f.End} {
+		if !s.seen(a) {
+			s.components++
+		}
+	}
+	if s.airports.union(f.Start, f.End) {
+		s.components--
+	}
+	// f.Start had no successor and f.End no predecessor, so f.Start stops
+	// being an end candidate or becomes a start one, and f.End stops being a
+	// start candidate or becomes an end one.
+	if _, ok := s.prev[f.Start]; ok {
+		delete(s.ends, f.Start)
+	} else {
+		s.starts[f.Start] = struct{}{}
+	}
+	if _, ok := s.next[f.End]; ok {
+		delete(s.starts, f.End)
+	} else {
+		s.ends[f.End] = struct{}{}
+	}
+	s.next[f.Start] = f.End
+	s.prev[f.End] = f.Start
+	return nil
+}
+
+// seen reports whether airport a appears in an accepted segment.
+func (s *itinerarySession) seen(a string) bool {
+	_, out := s.next[a]
+	_, in := s.prev[a]
+	return out || in
+}
+
+// state reports the session as FindItinerary would judge its segments: a
+// single path when there is one start candidate, one end candidate and one
+// connected component, circular when there is no start candidate, and
+// disconnected otherwise.
+func (s *itinerarySession) state() api.Session {
+	out := api.Session{
+		ID:         s.id,
+		Created:    s.created,
+		Segments:   len(s.segments),
+		Components: s.components,
+	}
+	switch {
+	case len(s.segments) == 0:
+		out.Status = sessionEmpty
+	case len(s.starts) == 0:
+		out.Status = sessionCircular
+	case len(s.starts) == 1 && len(s.ends) == 1 && s.components == 1:
+		out.Status = sessionValid
+		for a := range s.starts {
+			out.Start = a
+		}
+		for a := range s.ends {
+			out.End = a
+		}
+	default:
+		out.Status = sessionDisconnected
+		out.StartCandidates = sortedKeys(s.starts)
+		out.EndCandidates = sortedKeys(s.ends)
+	}
+	return out
+}
+
+// sortedKeys returns the members of set in ascending order.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// sessionRegistry holds the open sessions by ID, in memory. A session is
+// dropped once it has not been used for ttl; at most maxSessions are open at
+// once, each holding at most maxSegments segments.
+type sessionRegistry struct {
+	ttl         time.Duration
+	maxSessions int
+	maxSegments int
+	now         func() time.Time // the clock expiry is measured by
+
+	mu       sync.Mutex
+	sessions map[string]*itinerarySession
+}
+
+// newSessionRegistry returns an empty registry. Values below 1 fall back to
+// the defaults.
+func newSessionRegistry(ttl time.Duration, maxSessions, maxSegments int) *sessionRegistry {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	if maxSessions < 1 {
+		maxSessions = DefaultMaxSessions
+	}
+	if maxSegments < 1 {
+		maxSegments = DefaultMaxSessionSegments
+	}
+	return &sessionRegistry{
+		ttl:         ttl,
+		maxSessions: maxSessions,
+		maxSegments: maxSegments,
+		now:         time.Now,
+		sessions:    make(map[string]*itinerarySession),
+	}
+}
+
+// open creates and registers an empty session and returns it with its
+// expiry, or nil when maxSessions are already open.
+func (r *sessionRegistry) open() (*itinerarySession, time.Time) {
+	s := newItinerarySession()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.sessions) >= r.maxSessions {
+		return nil, time.Time{}
+	}
+	s.expires = r.now().UTC().Add(r.ttl)
+	s.timer = time.AfterFunc(r.ttl, func() { r.expire(s) })
+	r.sessions[s.id] = s
+	return s, s.expires
+}
+
+// get returns the session with the given ID and its new expiry, ttl from
+// now, or nil when there is no such session.
+func (r *sessionRegistry) get(id string) (*itinerarySession, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.sessions[id]
+	if s == nil {
+		return nil, time.Time{}
+	}
+	s.expires = r.now().UTC().Add(r.ttl)
+	return s, s.expires
+}
+
+// remove drops the session with the given ID and reports whether it existed.
+func (r *sessionRegistry) remove(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.sessions[id]
+	if s == nil {
+		return false
+	}
+	s.timer.Stop()
+	delete(r.sessions, id)
+	return true
+}
+
+// expire runs when s's timer fires: it drops s if it has been idle for ttl,
+// and otherwise waits until its current expiry. Touching a session therefore
+// only moves expires, never the timer.
+func (r *sessionRegistry) expire(s *itinerarySession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sessions[s.id] != s {
+		return
+	}
+	if wait := s.expires.Sub(r.now()); wait > 0 {
+		s.timer.Reset(wait)
+		return
+	}
+	delete(r.sessions, s.id)
+}
+
+// SessionCreate godoc
+// @Summary Open an incremental itinerary session.
+// @Description create an empty session; append segments one at a time with POST /sessions/{id}/segments and read the running start, end and status with GET /sessions/{id}. A session is dropped once it has not been used for SESSION_TTL (its Expires time, moved forward by every request for it), or with DELETE /sessions/{id}.
+// @Tags Sessions
+// @ID sessionCreate-post
+// @Produce json
+// @Success 201 {object} api.Session
+// @Header  201 {string} Location "/sessions/{id}"
+// @Failure 503 {object} map[string]interface{}	"Too many open sessions"
+// @Router /sessions [post].
+func (h Handler) SessionCreate(c *echo.Context) error {
+	s, expires := h.sessions.open()
+	if s == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]any{errorKey: "Too many open sessions"})
+	}
+	c.Response().Header().Set(echo.HeaderLocation, "/sessions/"+s.id)
+	state := s.state()
+	state.Expires = expires
+	return c.JSON(http.StatusCreated, state)
+}
+
+// SessionGet godoc
+// @Summary Get a session's current itinerary state.
+// @Description return the start, end and status of the segments appended so far. The state is kept up to date on every append, so reading it does not re-solve the itinerary.
+// @Tags Sessions
+// @ID sessionGet-get
+// @Produce json
+// @Param   id	path	string	true	"Session ID"
+// @Success 200 {object} api.Session
+// @Failure 404 {object} map[string]interface{}	"Session not found"
+// @Router /sessions/{id} [get].
+func (h Handler) SessionGet(c *echo.Context) error {
+	s, expires := h.sessions.get(c.Param("id"))
+	if s == nil {
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Session not found"})
+	}
+	s.mu.Lock()
+	state := s.state()
+	s.mu.Unlock()
+	state.Expires = expires
+	return c.JSON(http.StatusOK, state)
+}
+
+// SessionDelete godoc
+// @Summary Close a session.
+// @Description drop the session and its segments at once instead of waiting for it to expire.
+// @Tags Sessions
+// @ID sessionDelete-delete
+// @Param   id	path	string	true	"Session ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{}	"Session not found"
+// @Router /sessions/{id} [delete].
+func (h Handler) SessionDelete(c *echo.Context) error {
+	if !h.sessions.remove(c.Param("id")) {
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Session not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SessionAppend godoc
+// @Summary Append one segment to a session.
+// @Description add a single segment, validated and normalized like a segment of POST /calculate, and return the updated state. A segment that gives an airport a second distinct outgoing or incoming flight, or that would take the session past SESSION_MAX_SEGMENTS, is rejected with 409 and the session is unchanged; Indexes are session segment positions, the rejected one last.
+// @Tags Sessions
+// @ID sessionAppend-post
+// @Accept json
+// @Produce json
+// @Param   id	path	string	true	"Session ID"
+// @Param   segment	body	api.Segment	true	"One flight segment"
+// @Success 200 {object} api.Session
+// @Failure 400 {object} map[string]interface{}	"Bad Request"
+// @Failure 404 {object} map[string]interface{}	"Session not found"
+// @Failure 409 {object} map[string]interface{}	"Segment conflicts with the session"
+// @Router /sessions/{id}/segments [post].
+func (h Handler) SessionAppend(c *echo.Context) error {
+	s, expires := h.sessions.get(c.Param("id"))
+	if s == nil {
+		return c.JSON(http.StatusNotFound, map[string]any{errorKey: "Session not found"})
+	}
+
+	var raw json.RawMessage
+	if err := c.Bind(&raw); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: "Can't parse the payload"})
+	}
+	var seg api.Segment
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&seg); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: "Can't parse the payload"})
+	}
+	var rewrites []api.Rewrite
+	f, msg := segmentFlight(h.normalizeSegment(0, seg, &rewrites))
+	if msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]any{errorKey: msg})
+	}
+	if errBody := h.checkAirports(f); errBody != nil {
+		return c.JSON(http.StatusBadRequest, errBody)
+	}
+
+	var err error
+	s.mu.Lock()
+	full := len(s.segments) >= h.sessions.maxSegments
+	if !full {
+		err = s.add(f)
+	}
+	state := s.state()
+	s.mu.Unlock()
+	switch {
+	case full:
+		return c.JSON(http.StatusConflict, map[string]any{
+			errorKey: fmt.Sprintf("Session is full: it already holds the maximum of %d segments", h.sessions.maxSegments),
+		})
+	case err != nil:
+		return c.JSON(http.StatusConflict, itineraryErrorBody(err))
+	}
+	state.Expires = expires
+	return c.JSON(http.StatusOK, state)
+}
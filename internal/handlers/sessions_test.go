@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// TestSessionMatchesFindItinerary appends each payload one segment at a time
+// and checks that after every append the session agrees with FindItinerary
+// run from scratch on the segments so far.
+func TestSessionMatchesFindItinerary(t *testing.T) {
+	tests := []struct {
+		name    string
+		flights []api.Flight
+	}{
+		{name: "test flights", flights: api.TestFlights},
+		{name: "fragments joined last", flights: []api.Flight{
+			{Start: "SFO", End: "ATL"}, {Start: "GSO", End: "IND"}, {Start: "IND", End: "EWR"}, {Start: "ATL", End: "GSO"},
+		}},
+		{name: "reverse order", flights: []api.Flight{
+			{Start: "GSO", End: "EWR"}, {Start: "ATL", End: "GSO"}, {Start: "SFO", End: "ATL"},
+		}},
+		{name: "round trip", flights: []api.Flight{{Start: "SFO", End: "JFK"}, {Start: "JFK", End: "SFO"}}},
+		{name: "detached loop", flights: []api.Flight{
+			{Start: "SFO", End: "ATL"}, {Start: "JFK", End: "LHR"}, {Start: "LHR", End: "JFK"},
+		}},
+		{name: "duplicates", flights: []api.Flight{
+			{Start: "SFO", End: "ATL"}, {Start: "SFO", End: "ATL"}, {Start: "ATL", End: "EWR"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newItinerarySession()
+			for i, f := range tt.flights {
+				if err := s.add(f); err != nil {
+					t.Fatalf("add(%d): %v", i, err)
+				}
+				got := s.state()
+				start, end, err := FindItinerary(tt.flights[:i+1])
+				want := sessionValid
+				switch {
+				case errors.Is(err, ErrCircularPath):
+					want = sessionCircular
+				case errors.Is(err, ErrDisconnectedGraph):
+					want = sessionDisconnected
+				case err != nil:
+					t.Fatalf("FindItinerary(%d segments): %v", i+1, err)
+				}
+				if got.Status != want || got.Start != start || got.End != end || got.Segments != i+1 {
+					t.Errorf("after %d segments state = %+v, want %s %q -> %q", i+1, got, want, start, end)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionRejectsBranching(t *testing.T) {
+	s := newItinerarySession()
+	for _, f := range []api.Flight{{Start: "SFO", End: "ATL"}, {Start: "ATL", End: "EWR"}} {
+		if err := s.add(f); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	var branching *BranchingError
+	if err := s.add(api.Flight{Start: "ATL", End: "ORD"}); !errors.As(err, &branching) || branching.Airport != "ATL" || !branching.Outgoing {
+		t.Fatalf("add(ATL->ORD) error = %v, want an outgoing branch at ATL", err)
+	}
+	if err := s.add(api.Flight{Start: "JFK", End: "EWR"}); !errors.As(err, &branching) || branching.Airport != "EWR" || len(branching.Indexes) != 2 {
+		t.Fatalf("add(JFK->EWR) error = %v, want an incoming branch at EWR", err)
+	}
+	if err := s.add(api.Flight{Start: "SFO", End: "ATL", Number: "DL1"}); err != nil {
+		t.Fatalf("add(SFO->ATL DL1) error = %v, want the duplicate accepted", err)
+	}
+	if err := s.add(api.Flight{Start: "SFO", End: "ATL", Number: "DL2"}); !errors.As(err, &branching) || branching.Airport != "SFO" || !branching.Outgoing {
+		t.Fatalf("add(SFO->ATL DL2) error = %v, want an outgoing branch at SFO", err)
+	}
+	if got := s.state(); got.Status != sessionValid || got.Segments != 3 || got.Start != "SFO" || got.End != "EWR" {
+		t.Errorf("state after rejected appends = %+v, want the 2 accepted segments", got)
+	}
+}
+
+func TestSessionEndpoints(t *testing.T) {
+	h := New()
+
+	rec := serveRequest(t, h.SessionCreate, http.MethodPost, "/sessions", "", "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201", rec.Code)
+	}
+	var session api.Session
+	if err := json.Unmarshal(rec.Body.Bytes(), &session); err != nil {
+		t.Fatalf("failed to unmarshal session: %v", err)
+	}
+	if session.Status != sessionEmpty || rec.Header().Get("Location") != "/sessions/"+session.ID {
+		t.Errorf("session = %+v, Location = %q", session, rec.Header().Get("Location"))
+	}
+	id := session.ID
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "first", body: `{"from":"atl","to":"EWR"}`, wantCode: http.StatusOK, wantStatus: sessionValid},
+		{name: "fragment", body: `{"from":"SFO","to":"ORD","flight":"UA1"}`, wantCode: http.StatusOK, wantStatus: sessionDisconnected},
+		{name: "join", body: `{"from":"ORD","to":"ATL"}`, wantCode: http.StatusOK, wantStatus: sessionValid},
+		{name: "branch", body: `{"from":"ATL","to":"MIA"}`, wantCode: http.StatusConflict},
+		{name: "self loop", body: `{"from":"MIA","to":"MIA"}`, wantCode: http.StatusBadRequest},
+		{name: "array", body: `[["MIA","BOS"]]`, wantCode: http.StatusBadRequest},
+		{name: "unknown field", body: `{"from":"MIA","to":"BOS","gate":"B2"}`, wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveRequest(t, h.SessionAppend, http.MethodPost, "/sessions/"+id+"/segments", id, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got api.Session
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Status != tt.wantStatus {
+				t.Errorf("state = %+v (%v), want %s", got, err, tt.wantStatus)
+			}
+		})
+	}
+
+	rec = serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/"+id, id, "")
+	var got api.Session
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal session: %v", err)
+	}
+	if got.Status != sessionValid || got.Start != "SFO" || got.End != "EWR" || got.Segments != 3 || got.Components != 1 {
+		t.Errorf("GET state = %+v, want a valid SFO -> EWR path of 3 segments", got)
+	}
+
+	if rec := serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/nope", "nope", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session get status = %d, want 404", rec.Code)
+	}
+	rec = serveRequest(t, h.SessionAppend, http.MethodPost, "/sessions/nope/segments", "nope", `{"from":"A","to":"B"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session append status = %d, want 404", rec.Code)
+	}
+}
+
+// createSession opens a session on h and returns its ID.
+func createSession(t *testing.T, h Handler) string {
+	t.Helper()
+	rec := serveRequest(t, h.SessionCreate, http.MethodPost, "/sessions", "", "")
+	var session api.Session
+	if err := json.Unmarshal(rec.Body.Bytes(), &session); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	return session.ID
+}
+
+func TestSessionExpiry(t *testing.T) {
+	const ttl = time.Hour
+	h := New(WithSessions(ttl, 0, 0))
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	h.sessions.now = func() time.Time { return now }
+	id := createSession(t, h)
+	h.sessions.mu.Lock()
+	s := h.sessions.sessions[id]
+	h.sessions.mu.Unlock()
+
+	// A request moves the expiry forward, so when the timer set at creation
+	// fires the session is kept.
+	now = now.Add(40 * time.Minute)
+	rec := serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/"+id, id, "")
+	var got api.Session
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("get status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if want := now.Add(ttl); !got.Expires.Equal(want) {
+		t.Errorf("Expires = %v, want %v", got.Expires, want)
+	}
+	now = now.Add(20 * time.Minute)
+	h.sessions.expire(s)
+	if rec := serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/"+id, id, ""); rec.Code != http.StatusOK {
+		t.Fatalf("get of a session in use status = %d, want 200", rec.Code)
+	}
+
+	// Once idle for the whole TTL it is dropped.
+	now = now.Add(ttl)
+	h.sessions.expire(s)
+	if rec := serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/"+id, id, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expired session get status = %d, want 404", rec.Code)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	h := New(WithSessions(time.Minute, 2, 2))
+	id := createSession(t, h)
+	other := createSession(t, h)
+	if rec := serveRequest(t, h.SessionCreate, http.MethodPost, "/sessions", "", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("third session status = %d, want 503", rec.Code)
+	}
+
+	for _, body := range []string{`{"from":"SFO","to":"ATL"}`, `{"from":"ATL","to":"EWR"}`} {
+		if rec := serveRequest(t, h.SessionAppend, http.MethodPost, "/sessions/"+id+"/segments", id, body); rec.Code != http.StatusOK {
+			t.Fatalf("append status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+	}
+	rec := serveRequest(t, h.SessionAppend, http.MethodPost, "/sessions/"+id+"/segments", id, `{"from":"EWR","to":"BOS"}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("append past the limit status = %d, want 409", rec.Code)
+	}
+	rec = serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/"+id, id, "")
+	var got api.Session
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Segments != 2 || got.End != "EWR" {
+		t.Errorf("state after a refused append = %+v, want the 2 accepted segments", got)
+	}
+
+	if rec := serveRequest(t, h.SessionDelete, http.MethodDelete, "/sessions/"+other, other, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", rec.Code)
+	}
+	if rec := serveRequest(t, h.SessionDelete, http.MethodDelete, "/sessions/"+other, other, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want 404", rec.Code)
+	}
+	if rec := serveRequest(t, h.SessionGet, http.MethodGet, "/sessions/"+other, other, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted session get status = %d, want 404", rec.Code)
+	}
+	// Deleting frees a slot for a new session.
+	createSession(t, h)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+const (
+	// mimeNDJSON is the media type of newline-delimited JSON, used for both
+	// the request and the response of POST /calculate/stream.
+	mimeNDJSON = "application/x-ndjson"
+
+	// maxStreamLineBytes caps a single NDJSON line, matching the body limit
+	// applied to every other endpoint. The stream as a whole is unbounded.
+	maxStreamLineBytes = 1 << 20
+)
+
+// FlightStream godoc
+// @Summary Stream itineraries as newline-delimited JSON.
+// @Description read one itinerary per line of an application/x-ndjson body and write one result line back per input line, in order, as each is solved. The body is never buffered whole and is exempt from the 1 MiB request limit, which applies per line instead. Each line accepts the same shapes as POST /calculate; blank lines are skipped but still counted. The anchor, mode and sources query parameters apply to every line.
+// @Tags FlightCalculate
+// @ID flightStream-post
+// @Accept application/x-ndjson
+// @Produce application/x-ndjson
+// @Param   stream	body	api.CalculateRequest	true	"One CalculateRequest object (or legacy [][]string array) per line"
+// @Param   anchor	query	string	false	"Home airport used to break a round trip (only consulted for circular input)"
+// @Param   mode	query	string	false	"Solver: path (default, each airport visited once) or eulerian (repeated airports and duplicate legs)"	Enums(path, eulerian)
+// @Param   sources	query	string	false	"Comma-separated record sources, most trusted first, for merging segments tagged with a source (default SOURCE_PRIORITY)"
+// @Success 200 {object} api.StreamResult	"One StreamResult per input line"
+// @Failure 415 {object} map[string]interface{}	"Unsupported Media Type"
+// @Failure 500 {object} map[string]interface{}	"Internal Server Error"
+// @Router /calculate/stream [post].
+func (h Handler) FlightStream(c *echo.Context) error {
+	mediaType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil || mediaType != mimeNDJSON {
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]any{
+			errorKey: "Content-Type must be " + mimeNDJSON,
+		})
+	}
+
+	// HTTP/1.x servers stop reading the request once the response starts
+	// unless full duplex is enabled; recorders in tests do not support it.
+	_ = http.NewResponseController(c.Response()).EnableFullDuplex()
+
+	c.Response().Header().Set(echo.HeaderContentType, mimeNDJSON)
+	c.Response().WriteHeader(http.StatusOK)
+	h.solveStream(c.Request().Context(), c.Request().Body, c.Response(), queryOptions(c))
+	return nil
+}
+
+// solveStream solves each line of r and writes its api.StreamResult to w.
+// Output is flushed whenever no further input is already buffered, so a
+// client sending one line at a time sees each result immediately while bulk
+// uploads are not flushed line by line. A line longer than maxStreamLineBytes
+// ends the stream with an error line; a read error (typically the client
+// going away) ends it silently, and lines read once ctx is done report the
+// context error.
+func (h Handler) solveStream(ctx context.Context, r io.Reader, w http.ResponseWriter, override api.Options) {
+	in := bufio.NewReaderSize(r, maxStreamLineBytes)
+	enc := json.NewEncoder(w)
+	flush := func() { _ = http.NewResponseController(w).Flush() }
+	defer flush()
+
+	for n := 1; ; n++ {
+		line, err := in.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			_ = enc.Encode(api.StreamResult{Line: n, BatchResult: api.BatchResult{Error: "Line exceeds the 1 MiB limit"}})
+			return
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if enc.Encode(api.StreamResult{Line: n, BatchResult: h.solveEntry(ctx, line, override)}) != nil {
+				return
+			}
+			if in.Buffered() == 0 {
+				flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestFlightStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantLines   []api.StreamResult
+	}{
+		{
+			name:        "one result line per input line, blank lines counted",
+			contentType: "application/x-ndjson",
+			body: `[["ATL","EWR"],["SFO","ATL"]]
+{"segments":[{"from":"jfk","to":"LHR"}]}
+
+[["A","B"],["C","D"]]
+[["SFO"]]`,
+			wantStatus: http.StatusOK,
+			wantLines: []api.StreamResult{
+				{Line: 1, BatchResult: api.BatchResult{Start: "SFO", End: "EWR", Path: []string{"SFO", "ATL", "EWR"}}},
+				{Line: 2, BatchResult: api.BatchResult{Start: "JFK", End: "LHR", Path: []string{"JFK", "LHR"}}},
+				{Line: 4, BatchResult: api.BatchResult{Error: "disconnected graph: multiple distinct itineraries detected (start candidates [A C], end candidates [B D])"}},
+				{Line: 5, BatchResult: api.BatchResult{Error: "Each flight segment must contain both source and destination"}},
+			},
+		},
+		{
+			name:        "query parameters apply to every line",
+			target:      "/calculate/stream?anchor=A",
+			contentType: "application/x-ndjson; charset=utf-8",
+			body:        "[[\"A\",\"B\"],[\"B\",\"A\"]]\r\n",
+			wantStatus:  http.StatusOK,
+			wantLines: []api.StreamResult{
+				{Line: 1, BatchResult: api.BatchResult{Start: "A", End: "A", Path: []string{"A", "B", "A"}}},
+			},
+		},
+		{
+			name:        "oversized line ends the stream",
+			contentType: "application/x-ndjson",
+			body:        `[["SFO","EWR"]]` + "\n" + `[["` + strings.Repeat("A", maxStreamLineBytes) + `","B"]]` + "\n" + `[["SFO","EWR"]]`,
+			wantStatus:  http.StatusOK,
+			wantLines: []api.StreamResult{
+				{Line: 1, BatchResult: api.BatchResult{Start: "SFO", End: "EWR", Path: []string{"SFO", "EWR"}}},
+				{Line: 2, BatchResult: api.BatchResult{Error: "Line exceeds the 1 MiB limit"}},
+			},
+		},
+		{
+			name:        "JSON content type returns 415",
+			contentType: "application/json",
+			body:        `[["SFO","EWR"]]`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/calculate/stream"
+			}
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			if err := New().FlightStream(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); ct != mimeNDJSON {
+				t.Errorf("Content-Type = %q, want %q", ct, mimeNDJSON)
+			}
+			var got []api.StreamResult
+			for line := range strings.Lines(rec.Body.String()) {
+				var r api.StreamResult
+				if err := json.Unmarshal([]byte(line), &r); err != nil {
+					t.Fatalf("failed to unmarshal line %q: %v", line, err)
+				}
+				got = append(got, r)
+			}
+			if len(got) != len(tt.wantLines) {
+				t.Fatalf("got %d lines, want %d: %s", len(got), len(tt.wantLines), rec.Body.String())
+			}
+			for i, want := range tt.wantLines {
+				r := got[i]
+				if r.Line != want.Line || r.Start != want.Start || r.End != want.End || !slices.Equal(r.Path, want.Path) || r.Error != want.Error {
+					t.Errorf("line %d = %+v, want %+v", i, r, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSolveStreamAnswersBeforeEOF feeds the stream through a pipe and reads
+// each result before sending the next line, so a handler that buffered the
+// body would deadlock.
+func TestSolveStreamAnswersBeforeEOF(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer outW.Close()
+		New().solveStream(context.Background(), inR, &pipeResponseWriter{Writer: outW, header: http.Header{}}, api.Options{})
+	}()
+
+	results := bufio.NewScanner(outR)
+	for i, line := range []string{`[["SFO","ATL"]]`, `[["ATL","EWR"]]`, `[["EWR","JFK"]]`} {
+		if _, err := io.WriteString(inW, line+"\n"); err != nil {
+			t.Fatalf("write line %d: %v", i+1, err)
+		}
+		if !results.Scan() {
+			t.Fatalf("no result for line %d: %v", i+1, results.Err())
+		}
+		var r api.StreamResult
+		if err := json.Unmarshal(results.Bytes(), &r); err != nil || r.Line != i+1 || r.Error != "" {
+			t.Fatalf("result for line %d = %s (%v)", i+1, results.Text(), err)
+		}
+	}
+	inW.Close()
+	<-done
+}
+
+// pipeResponseWriter is a minimal http.ResponseWriter over an io.Writer.
+type pipeResponseWriter struct {
+	io.Writer
+	header http.Header
+}
+
+func (w *pipeResponseWriter) Header() http.Header { return w.header }
+
+func (w *pipeResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// ErrUnknownAirport is returned when an analysis needs reference data for an
+// airport that is not in the registry. The concrete error is an
+// *UnknownAirportError naming the airport.
+var ErrUnknownAirport = errors.New("unknown airport: no reference data for the airport code")
+
+// UnknownAirportError names the airport missing from the registry. It matches
+// ErrUnknownAirport via errors.Is.
+type UnknownAirportError struct {
+	Airport string
+}
+
+func (e *UnknownAirportError) Error() string {
+	return fmt.Sprintf("unknown airport: no reference data for %s", e.Airport)
+}
+
+// Is reports whether target is ErrUnknownAirport.
+func (e *UnknownAirportError) Is(target error) bool {
+	return target == ErrUnknownAirport
+}
+
+// Flight-time estimate: a fixed allowance for taxi, climb and descent plus
+// cruise at a typical jet ground speed.
+const (
+	taxiAllowanceMinutes = 30
+	cruiseSpeedKmh       = 800
+)
+
+// Unit conversions from kilometres.
+const (
+	kmPerMile         = 1.609344
+	kmPerNauticalMile = 1.852
+)
+
+// SummarizeItinerary computes per-leg and total great-circle distances for
+// itinerary using the coordinates in reg, the direct distance between origin
+// and final destination, the detour ratio between the two, and flight times:
+// an estimate for every leg (taxiAllowanceMinutes plus cruise at
+// cruiseSpeedKmh) and the scheduled time for legs with both timestamps. An
+// airport missing from reg yields an *UnknownAirportError.
+// Time complexity: O(n), space complexity: O(n).
+func SummarizeItinerary(itinerary api.Itinerary, reg *airports.Registry) (api.Summary, error) {
+	if len(itinerary.Path) == 0 {
+		return api.Summary{}, nil
+	}
+
+	summary := api.Summary{Legs: make([]api.LegSummary, 0, len(itinerary.Legs))}
+	var totalKm float64
+	for _, leg := range itinerary.Legs {
+		km, err := legKm(reg, leg)
+		if err != nil {
+			return api.Summary{}, err
+		}
+		totalKm += km
+		ls := api.LegSummary{
+			Start:            leg.Start,
+			End:              leg.End,
+			Distance:         newDistance(km),
+			EstimatedMinutes: estimateMinutes(km),
+		}
+		if !leg.Departure.IsZero() && !leg.Arrival.IsZero() {
+			ls.ScheduledMinutes = int(leg.Arrival.Sub(leg.Departure).Minutes())
+		}
+		summary.Legs = append(summary.Legs, ls)
+		summary.EstimatedMinutes += ls.EstimatedMinutes
+		summary.ScheduledMinutes += ls.ScheduledMinutes
+	}
+
+	directKm, err := legKm(reg, api.Flight{Start: itinerary.Path[0], End: itinerary.Path[len(itinerary.Path)-1]})
+	if err != nil {
+		return api.Summary{}, err
+	}
+	summary.Total = newDistance(totalKm)
+	summary.Direct = newDistance(directKm)
+	if directKm > 0 {
+		summary.DetourRatio = math.Round(totalKm/directKm*1000) / 1000
+	}
+	return summary, nil
+}
+
+// legKm returns the great-circle distance of leg in kilometres, or an
+// *UnknownAirportError when either airport is missing from reg.
+func legKm(reg *airports.Registry, leg api.Flight) (float64, error) {
+	from, ok := reg.Lookup(leg.Start)
+	if !ok {
+		return 0, &UnknownAirportError{Airport: leg.Start}
+	}
+	to, ok := reg.Lookup(leg.End)
+	if !ok {
+		return 0, &UnknownAirportError{Airport: leg.End}
+	}
+	return airports.Distance(from, to), nil
+}
+
+// newDistance expresses km in every unit, rounded to one decimal place.
+func newDistance(km float64) api.Distance {
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return api.Distance{
+		Km: round(km),
+		Mi: round(km / kmPerMile),
+		Nm: round(km / kmPerNauticalMile),
+	}
+}
+
+// estimateMinutes estimates the block time of a leg of km kilometres.
+func estimateMinutes(km float64) int {
+	return taxiAllowanceMinutes + int(math.Round(km/cruiseSpeedKmh*60))
+}
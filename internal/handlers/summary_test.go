@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/airports"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestSummarizeItinerary(t *testing.T) {
+	reg := airports.Default()
+	tests := []struct {
+		name          string
+		flights       []api.Flight
+		wantTotalKm   float64
+		wantDirectKm  float64
+		wantRatio     float64
+		wantScheduled int
+		wantErr       error
+	}{
+		{
+			name:         "single leg has no detour",
+			flights:      []api.Flight{{Start: "JFK", End: "LHR"}},
+			wantTotalKm:  5540,
+			wantDirectKm: 5540,
+			wantRatio:    1,
+		},
+		{
+			name:         "connection through a hub",
+			flights:      []api.Flight{{Start: "ATL", End: "EWR"}, {Start: "SFO", End: "ATL"}},
+			wantTotalKm:  3442 + 1199,
+			wantDirectKm: 4130,
+			wantRatio:    1.124,
+		},
+		{
+			name: "scheduled minutes from timestamps",
+			flights: []api.Flight{{
+				Start: "SFO", End: "ATL",
+				Departure: at(t, "2026-03-01T06:00:00Z"), Arrival: at(t, "2026-03-01T10:45:00Z"),
+			}},
+			wantTotalKm:   3442,
+			wantDirectKm:  3442,
+			wantRatio:     1,
+			wantScheduled: 285,
+		},
+		{
+			name:    "unknown airport",
+			flights: []api.Flight{{Start: "SFO", End: "XXX"}},
+			wantErr: ErrUnknownAirport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itinerary, err := ReconstructItinerary(tt.flights)
+			if err != nil {
+				t.Fatalf("ReconstructItinerary: %v", err)
+			}
+			got, err := SummarizeItinerary(itinerary, reg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(got.Legs) != len(tt.flights) {
+				t.Fatalf("legs = %d, want %d", len(got.Legs), len(tt.flights))
+			}
+			if !near(got.Total.Km, tt.wantTotalKm) || !near(got.Direct.Km, tt.wantDirectKm) {
+				t.Errorf("Total = %v, Direct = %v, want ~%.0f / ~%.0f km", got.Total, got.Direct, tt.wantTotalKm, tt.wantDirectKm)
+			}
+			if math.Abs(got.DetourRatio-tt.wantRatio) > 0.01 {
+				t.Errorf("DetourRatio = %v, want ~%v", got.DetourRatio, tt.wantRatio)
+			}
+			if !near(got.Total.Mi*kmPerMile, got.Total.Km) || !near(got.Total.Nm*kmPerNauticalMile, got.Total.Km) {
+				t.Errorf("unit conversions disagree: %+v", got.Total)
+			}
+			if got.ScheduledMinutes != tt.wantScheduled {
+				t.Errorf("ScheduledMinutes = %d, want %d", got.ScheduledMinutes, tt.wantScheduled)
+			}
+			if got.EstimatedMinutes <= taxiAllowanceMinutes*len(tt.flights) {
+				t.Errorf("EstimatedMinutes = %d, want more than the taxi allowance", got.EstimatedMinutes)
+			}
+		})
+	}
+}
+
+func TestSummarizeRoundTripOmitsDetourRatio(t *testing.T) {
+	itinerary, err := ReconstructItineraryFrom([]api.Flight{{Start: "SFO", End: "JFK"}, {Start: "JFK", End: "SFO"}}, "SFO")
+	if err != nil {
+		t.Fatalf("ReconstructItineraryFrom: %v", err)
+	}
+	got, err := SummarizeItinerary(itinerary, airports.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Direct.Km != 0 || got.DetourRatio != 0 || got.Total.Km == 0 {
+		t.Errorf("summary = %+v, want zero direct distance and no detour ratio", got)
+	}
+}
+
+func TestFlightSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantAirport string
+	}{
+		{
+			name:       "known airports",
+			body:       `[["ATL","EWR"],["SFO","ATL"]]`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "unknown airport returns 400 with Airport",
+			body:        `[["SFO","XXX"]]`,
+			wantStatus:  http.StatusBadRequest,
+			wantAirport: "XXX",
+		},
+		{
+			name:       "circular path returns 400",
+			body:       `[["SFO","ATL"],["ATL","SFO"]]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/calculate/summary", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "application/json")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			if err := New().FlightSummary(c); err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantAirport != "" {
+				var env map[string]any
+				if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if env[airportKey] != tt.wantAirport {
+					t.Errorf("Airport = %v, want %s", env[airportKey], tt.wantAirport)
+				}
+			}
+		})
+	}
+}
+
+// near reports whether got is within 0.5% (or 0.1 km) of want.
+func near(got, want float64) bool {
+	return math.Abs(got-want) <= math.Max(want*0.005, 0.1)
+}
@@ -0,0 +1,257 @@
+// Package jobs runs calculations asynchronously on a fixed pool of workers,
+// keeps each job's outcome for a limited time after it finishes and, when the
+// submission asked for it, delivers the finished job to a callback URL.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/webhook"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// Job statuses, as reported in api.Job.Status.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+// Callback delivery statuses, as reported in api.Callback.Status.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Defaults for New used when no configuration is given.
+const (
+	DefaultCapacity = 1000
+	DefaultTTL      = 15 * time.Minute
+)
+
+var (
+	// ErrNotFound is returned for an unknown job ID, including one whose
+	// result has expired.
+	ErrNotFound = errors.New("job not found")
+
+	// ErrQueueFull is returned by Submit when the queue already holds its
+	// capacity of jobs waiting for a worker.
+	ErrQueueFull = errors.New("job queue is full")
+
+	// ErrFinished is returned by Cancel for a job that has already finished.
+	ErrFinished = errors.New("job already finished")
+
+	// ErrCallbacksDisabled is returned by Submit for a job with a callback
+	// URL when the queue has no webhook sender.
+	ErrCallbacksDisabled = errors.New("callbacks are disabled")
+)
+
+// Task is the work a job performs: it records the outcome in job.Result or
+// job.Batch. The context is canceled when the job is; a Result with a
+// non-empty Error marks the job failed.
+type Task func(ctx context.Context, job *api.Job)
+
+// Queue runs submitted tasks on a fixed number of workers in submission
+// order. Finished jobs are kept for the queue's TTL and then dropped. A Queue
+// is safe for concurrent use.
+type Queue struct {
+	ttl      time.Duration
+	capacity int
+	sender   *webhook.Sender
+
+	mu      sync.Mutex
+	ready   *sync.Cond // signaled on mu when a job is queued
+	waiting []*entry   // queued jobs in submission order
+	jobs    map[string]*entry
+}
+
+// entry is a job with its task; cancel is set while the task runs.
+type entry struct {
+	job    api.Job
+	task   Task
+	cancel context.CancelFunc
+}
+
+// New starts workers goroutines serving a queue that holds at most capacity
+// waiting jobs and keeps finished jobs for ttl. Jobs submitted with a
+// callback URL are delivered through sender; a nil sender disables
+// callbacks. Workers and capacity below 1 are raised to 1.
+func New(workers, capacity int, ttl time.Duration, sender *webhook.Sender) *Queue {
+	q := &Queue{
+		ttl:      ttl,
+		capacity: max(capacity, 1),
+		sender:   sender,
+		jobs:     make(map[string]*entry),
+	}
+	q.ready = sync.NewCond(&q.mu)
+	for range max(workers, 1) {
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues task and returns the new job, or ErrQueueFull when capacity
+// jobs are already waiting; canceled jobs do not count. When
+// callback is non-empty the job is POSTed there once it succeeds or fails;
+// a URL the sender rejects returns an error matching webhook.ErrInvalidURL
+// or webhook.ErrForbiddenHost and a queue without a sender returns
+// ErrCallbacksDisabled.
+func (q *Queue) Submit(task Task, callback string) (api.Job, error) {
+	e := &entry{
+		job:  api.Job{ID: rand.Text(), Status: StatusQueued, Submitted: time.Now().UTC()},
+		task: task,
+	}
+	if callback != "" {
+		if q.sender == nil {
+			return api.Job{}, ErrCallbacksDisabled
+		}
+		if err := q.sender.CheckURL(callback); err != nil {
+			return api.Job{}, err
+		}
+		e.job.Callback = &api.Callback{URL: callback, Status: DeliveryPending}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) >= q.capacity {
+		return api.Job{}, ErrQueueFull
+	}
+	q.waiting = append(q.waiting, e)
+	q.jobs[e.job.ID] = e
+	q.ready.Signal()
+	return snapshot(e.job), nil
+}
+
+// Get returns the current state of job id, or ErrNotFound.
+func (q *Queue) Get(id string) (api.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return api.Job{}, ErrNotFound
+	}
+	return snapshot(e.job), nil
+}
+
+// Cancel stops job id: a queued job leaves the queue, freeing its place,
+// and never runs, and a running job's context is canceled and its result
+// discarded. It returns the canceled job, or
+// ErrNotFound or ErrFinished.
+func (q *Queue) Cancel(id string) (api.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return api.Job{}, ErrNotFound
+	}
+	if e.job.Status != StatusQueued && e.job.Status != StatusRunning {
+		return snapshot(e.job), ErrFinished
+	}
+	if e.job.Status == StatusQueued {
+		q.waiting = slices.DeleteFunc(q.waiting, func(w *entry) bool { return w == e })
+	}
+	q.finish(e, StatusCanceled, nil)
+	return snapshot(e.job), nil
+}
+
+// work runs queued jobs in submission order until the process exits.
+// Cancel removes a queued job under the same lock, so a worker only takes
+// jobs that are still queued.
+func (q *Queue) work() {
+	for {
+		q.mu.Lock()
+		for len(q.waiting) == 0 {
+			q.ready.Wait()
+		}
+		e := q.waiting[0]
+		q.waiting[0] = nil
+		q.waiting = q.waiting[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		e.cancel = cancel
+		e.job.Status = StatusRunning
+		e.job.Started = time.Now().UTC()
+		q.mu.Unlock()
+
+		var outcome api.Job
+		e.task(ctx, &outcome)
+
+		q.mu.Lock()
+		if e.job.Status == StatusRunning {
+			status := StatusSucceeded
+			if outcome.Result != nil && outcome.Result.Error != "" {
+				status = StatusFailed
+			}
+			q.finish(e, status, &outcome)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// finish records the outcome of e, starts its callback delivery unless it
+// was canceled, and schedules its removal after the TTL. outcome is nil for
+// a canceled job. The caller must hold q.mu.
+func (q *Queue) finish(e *entry, status string, outcome *api.Job) {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	now := time.Now().UTC()
+	e.job.Status = status
+	if outcome != nil {
+		e.job.Result = outcome.Result
+		e.job.Batch = outcome.Batch
+	}
+	e.job.Finished = now
+	e.job.Expires = now.Add(q.ttl)
+	if e.job.Callback != nil {
+		if status == StatusCanceled {
+			e.job.Callback = nil
+		} else {
+			go q.deliver(e, snapshot(e.job))
+		}
+	}
+	time.AfterFunc(q.ttl, func() {
+		q.mu.Lock()
+		delete(q.jobs, e.job.ID)
+		q.mu.Unlock()
+	})
+}
+
+// deliver POSTs the finished job to its callback URL, recording each attempt
+// and the final delivery status on e. The payload omits the Callback field,
+// which the receiver already knows.
+func (q *Queue) deliver(e *entry, job api.Job) {
+	target := job.Callback.URL
+	job.Callback = nil
+	delivered := q.sender.Deliver(context.Background(), target, job, func(a api.DeliveryAttempt) {
+		q.mu.Lock()
+		e.job.Callback.Attempts = append(e.job.Callback.Attempts, a)
+		q.mu.Unlock()
+	})
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if delivered {
+		e.job.Callback.Status = DeliveryDelivered
+	} else {
+		e.job.Callback.Status = DeliveryFailed
+	}
+}
+
+// snapshot copies job so callers can read it without holding q.mu while
+// delivery keeps appending to the callback's attempts.
+func snapshot(job api.Job) api.Job {
+	if job.Callback != nil {
+		cb := *job.Callback
+		cb.Attempts = slices.Clone(cb.Attempts)
+		job.Callback = &cb
+	}
+	return job
+}
@@ -0,0 +1,178 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/webhook"
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// waitFor polls job id until its status is no longer queued or running.
+func waitFor(t *testing.T, q *Queue, id string) api.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", id, err)
+		}
+		if job.Status != StatusQueued && job.Status != StatusRunning {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return api.Job{}
+}
+
+func TestQueueRunsTasks(t *testing.T) {
+	q := New(2, 10, time.Minute, nil)
+	ok, err := q.Submit(func(_ context.Context, job *api.Job) {
+		job.Result = &api.BatchResult{Start: "SFO", End: "EWR"}
+	}, "")
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if ok.Status != StatusQueued || ok.ID == "" || ok.Submitted.IsZero() {
+		t.Errorf("submitted job = %+v", ok)
+	}
+	bad, _ := q.Submit(func(_ context.Context, job *api.Job) {
+		job.Result = &api.BatchResult{Error: "circular path"}
+	}, "")
+
+	got := waitFor(t, q, ok.ID)
+	if got.Status != StatusSucceeded || got.Result == nil || got.Result.Start != "SFO" {
+		t.Errorf("succeeded job = %+v", got)
+	}
+	if got.Started.IsZero() || got.Finished.IsZero() || !got.Expires.Equal(got.Finished.Add(time.Minute)) {
+		t.Errorf("timestamps = started %v, finished %v, expires %v", got.Started, got.Finished, got.Expires)
+	}
+	if got := waitFor(t, q, bad.ID); got.Status != StatusFailed || got.Result.Error != "circular path" {
+		t.Errorf("failed job = %+v", got)
+	}
+	if _, err := q.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	q := New(1, 1, time.Minute, nil)
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	running, _ := q.Submit(func(ctx context.Context, job *api.Job) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		job.Result = &api.BatchResult{Start: "SFO"}
+	}, "")
+	<-started
+	queued, _ := q.Submit(func(context.Context, *api.Job) {
+		t.Error("canceled queued job ran")
+	}, "")
+	if _, err := q.Submit(func(context.Context, *api.Job) {}, ""); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit on a full queue error = %v, want ErrQueueFull", err)
+	}
+
+	job, err := q.Cancel(queued.ID)
+	if err != nil || job.Status != StatusCanceled {
+		t.Errorf("Cancel(queued) = %+v, %v", job, err)
+	}
+	// The canceled job no longer holds the only place in the queue.
+	refill, err := q.Submit(func(context.Context, *api.Job) {}, "")
+	if err != nil {
+		t.Fatalf("Submit after canceling the queued job: %v", err)
+	}
+	job, err = q.Cancel(running.ID)
+	if err != nil || job.Status != StatusCanceled {
+		t.Errorf("Cancel(running) = %+v, %v", job, err)
+	}
+	<-stopped
+	if job := waitFor(t, q, running.ID); job.Status != StatusCanceled || job.Result != nil {
+		t.Errorf("canceled running job = %+v, want canceled without result", job)
+	}
+	if job := waitFor(t, q, refill.ID); job.Status != StatusSucceeded {
+		t.Errorf("job queued after a cancel = %+v, want succeeded", job)
+	}
+	if _, err := q.Cancel(running.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("second Cancel error = %v, want ErrFinished", err)
+	}
+	if _, err := q.Cancel("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueueExpiresResults(t *testing.T) {
+	q := New(1, 1, 10*time.Millisecond, nil)
+	job, _ := q.Submit(func(context.Context, *api.Job) {}, "")
+	waitFor(t, q, job.ID)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := q.Get(job.ID); errors.Is(err, ErrNotFound) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("job %s still present after its TTL", job.ID)
+}
+
+func TestQueueDeliversCallback(t *testing.T) {
+	received := make(chan api.Job, 1)
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var job api.Job
+		_ = json.NewDecoder(r.Body).Decode(&job)
+		received <- job
+	}))
+	defer receiver.Close()
+
+	q := New(1, 1, time.Minute, webhook.New("secret", 3, time.Millisecond, []string{"127.0.0.1"}))
+	submitted, err := q.Submit(func(_ context.Context, job *api.Job) {
+		job.Result = &api.BatchResult{Start: "SFO", End: "EWR"}
+	}, receiver.URL)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if submitted.Callback == nil || submitted.Callback.Status != DeliveryPending {
+		t.Errorf("submitted Callback = %+v, want pending", submitted.Callback)
+	}
+
+	payload := <-received
+	if payload.ID != submitted.ID || payload.Status != StatusSucceeded || payload.Result.Start != "SFO" || payload.Callback != nil {
+		t.Errorf("payload = %+v", payload)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := q.Get(submitted.ID)
+		if job.Callback.Status == DeliveryDelivered {
+			if len(job.Callback.Attempts) != 2 || job.Callback.Attempts[0].StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("Attempts = %+v, want a 503 then a success", job.Callback.Attempts)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("callback not delivered: %+v", job.Callback)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := q.Submit(func(context.Context, *api.Job) {}, "ftp://example.com"); !errors.Is(err, webhook.ErrInvalidURL) {
+		t.Errorf("Submit(ftp) error = %v, want ErrInvalidURL", err)
+	}
+	if _, err := q.Submit(func(context.Context, *api.Job) {}, "http://169.254.169.254/latest"); !errors.Is(err, webhook.ErrForbiddenHost) {
+		t.Errorf("Submit(link-local) error = %v, want ErrForbiddenHost", err)
+	}
+	if _, err := New(1, 1, time.Minute, nil).Submit(func(context.Context, *api.Job) {}, receiver.URL); !errors.Is(err, ErrCallbacksDisabled) {
+		t.Errorf("Submit without a sender error = %v, want ErrCallbacksDisabled", err)
+	}
+}
@@ -10,4 +10,12 @@ import (
 // FlightRoutes sets up routes for the flight calculations.
 func FlightRoutes(e *echo.Echo, h *handlers.Handler) {
 	e.POST("/calculate", h.FlightCalculate)
+	e.POST("/calculate/itinerary", h.FlightItinerary)
+	e.POST("/calculate/components", h.FlightComponents)
+	e.POST("/calculate/gaps", h.FlightGaps)
+	e.POST("/calculate/summary", h.FlightSummary)
+	e.POST("/calculate/emissions", h.FlightEmissions)
+	e.POST("/calculate/countries", h.FlightCountries)
+	e.POST("/calculate/batch", h.FlightBatch)
+	e.POST("/calculate/stream", h.FlightStream)
 }
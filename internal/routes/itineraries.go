@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/handlers"
+)
+
+// ItineraryRoutes sets up routes for saved itineraries.
+func ItineraryRoutes(e *echo.Echo, h *handlers.Handler) {
+	e.POST("/itineraries", h.ItinerarySave)
+	e.GET("/itineraries", h.ItineraryList)
+	e.GET("/itineraries/:id", h.ItineraryGet)
+	e.PATCH("/itineraries/:id", h.ItineraryPatch)
+	e.GET("/itineraries/:id/versions", h.ItineraryVersions)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/handlers"
+)
+
+// JobRoutes sets up routes for asynchronous jobs.
+func JobRoutes(e *echo.Echo, h *handlers.Handler) {
+	e.POST("/jobs", h.JobSubmit)
+	e.GET("/jobs/:id", h.JobGet)
+	e.DELETE("/jobs/:id", h.JobCancel)
+}
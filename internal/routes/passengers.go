@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/handlers"
+)
+
+// PassengerRoutes sets up routes for passengers and their segments.
+func PassengerRoutes(e *echo.Echo, h *handlers.Handler) {
+	e.POST("/passengers", h.PassengerCreate)
+	e.GET("/passengers/:id", h.PassengerGet)
+	e.POST("/passengers/:id/segments", h.PassengerAppend)
+	e.GET("/passengers/:id/location", h.PassengerLocate)
+	e.GET("/passengers/:id/contacts", h.PassengerContacts)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v5"
+
+	"github.com/AndriyKalashnykov/flight-path/internal/handlers"
+)
+
+// SessionRoutes sets up routes for incremental itinerary sessions.
+func SessionRoutes(e *echo.Echo, h *handlers.Handler) {
+	e.POST("/sessions", h.SessionCreate)
+	e.GET("/sessions/:id", h.SessionGet)
+	e.DELETE("/sessions/:id", h.SessionDelete)
+	e.POST("/sessions/:id/segments", h.SessionAppend)
+}
@@ -0,0 +1,335 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// itinerariesBucket and passengersBucket hold one JSON-encoded
+// api.StoredItinerary (the current version) or api.Passenger per key; keys
+// are the big-endian ID sequence so that cursor order is creation order.
+// versionsBucket holds one nested bucket per itinerary, named by its ID
+// sequence, with every version keyed by the nested bucket's own sequence,
+// which therefore matches the Version.
+var (
+	itinerariesBucket = []byte("itineraries")
+	passengersBucket  = []byte("passengers")
+	versionsBucket    = []byte("itinerary_versions")
+)
+
+// openTimeout bounds the wait for the file lock held by another process.
+const openTimeout = time.Second
+
+// Bolt is a Store backed by an embedded bbolt database file, so saved
+// itineraries and passengers survive restarts.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (creating if needed) the bbolt database at path.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{itinerariesBucket, passengersBucket, versionsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return addVersionHistory(tx)
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("init store %s: %w", path, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+// Create implements Store.
+func (b *Bolt) Create(it api.StoredItinerary) (api.StoredItinerary, error) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(itinerariesBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		it.ID = strconv.FormatUint(seq, 10)
+		it.Created = time.Now().UTC()
+		it.Version = 1
+		it.Modified = it.Created
+		return putItinerary(tx, seq, it)
+	})
+	if err != nil {
+		return api.StoredItinerary{}, fmt.Errorf("save itinerary: %w", err)
+	}
+	return it, nil
+}
+
+// Get implements Store.
+func (b *Bolt) Get(id string) (api.StoredItinerary, error) {
+	var it api.StoredItinerary
+	err := b.db.View(func(tx *bolt.Tx) error {
+		_, err := getItinerary(tx, id, &it)
+		return err
+	})
+	return it, err
+}
+
+// List implements Store.
+func (b *Bolt) List(offset, limit int) ([]api.StoredItinerary, int, error) {
+	var page []api.StoredItinerary
+	var total int
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(itinerariesBucket)
+		total = bucket.Stats().KeyN
+		page = make([]api.StoredItinerary, 0, max(min(limit, total-offset), 0))
+		c := bucket.Cursor()
+		k, v := c.First()
+		for i := 0; k != nil && i < offset; i++ {
+			k, v = c.Next()
+		}
+		for ; k != nil && len(page) < limit; k, v = c.Next() {
+			var it api.StoredItinerary
+			if err := json.Unmarshal(v, &it); err != nil {
+				return err
+			}
+			page = append(page, it)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list itineraries: %w", err)
+	}
+	return page, total, nil
+}
+
+// Update implements Store.
+func (b *Bolt) Update(id string, change func(api.StoredItinerary) (api.StoredItinerary, error)) (api.StoredItinerary, error) {
+	var next api.StoredItinerary
+	var changeErr error
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		var current api.StoredItinerary
+		seq, err := getItinerary(tx, id, &current)
+		if err != nil {
+			return err
+		}
+		if next, changeErr = change(current); changeErr != nil {
+			return changeErr
+		}
+		next = nextVersion(current, next)
+		return putItinerary(tx, seq, next)
+	})
+	switch {
+	case changeErr != nil, errors.Is(err, ErrNotFound):
+		return api.StoredItinerary{}, err
+	case err != nil:
+		return api.StoredItinerary{}, fmt.Errorf("update itinerary: %w", err)
+	}
+	return next, nil
+}
+
+// Versions implements Store.
+func (b *Bolt) Versions(id string) ([]api.StoredItinerary, error) {
+	var versions []api.StoredItinerary
+	err := b.db.View(func(tx *bolt.Tx) error {
+		var current api.StoredItinerary
+		seq, err := getItinerary(tx, id, &current)
+		if err != nil {
+			return err
+		}
+		versions = make([]api.StoredItinerary, 0, current.Version)
+		return tx.Bucket(versionsBucket).Bucket(seqKey(seq)).ForEach(func(_, v []byte) error {
+			var it api.StoredItinerary
+			if err := json.Unmarshal(v, &it); err != nil {
+				return err
+			}
+			versions = append(versions, it)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// addVersionHistory makes every itinerary saved before versions were kept
+// its own version 1.
+func addVersionHistory(tx *bolt.Tx) error {
+	var unversioned []api.StoredItinerary
+	versions := tx.Bucket(versionsBucket)
+	err := tx.Bucket(itinerariesBucket).ForEach(func(k, v []byte) error {
+		if versions.Bucket(k) != nil {
+			return nil
+		}
+		var it api.StoredItinerary
+		if err := json.Unmarshal(v, &it); err != nil {
+			return err
+		}
+		unversioned = append(unversioned, it)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, it := range unversioned {
+		seq, err := strconv.ParseUint(it.ID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("itinerary ID %q: %w", it.ID, err)
+		}
+		it.Version = 1
+		it.Modified = it.Created
+		if err := putItinerary(tx, seq, it); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getItinerary reads the current version of itinerary id into it and
+// returns its key sequence, or ErrNotFound.
+func getItinerary(tx *bolt.Tx, id string, it *api.StoredItinerary) (uint64, error) {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, ErrNotFound
+	}
+	data := tx.Bucket(itinerariesBucket).Get(seqKey(seq))
+	if data == nil {
+		return 0, ErrNotFound
+	}
+	return seq, json.Unmarshal(data, it)
+}
+
+// putItinerary saves it as both the current version of itinerary seq and
+// an entry of its history.
+func putItinerary(tx *bolt.Tx, seq uint64, it api.StoredItinerary) error {
+	data, err := json.Marshal(it)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(itinerariesBucket).Put(seqKey(seq), data); err != nil {
+		return err
+	}
+	history, err := tx.Bucket(versionsBucket).CreateBucketIfNotExists(seqKey(seq))
+	if err != nil {
+		return err
+	}
+	version, err := history.NextSequence()
+	if err != nil {
+		return err
+	}
+	return history.Put(seqKey(version), data)
+}
+
+// CreatePassenger implements Store.
+func (b *Bolt) CreatePassenger(p api.Passenger) (api.Passenger, error) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(passengersBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		p.ID = strconv.FormatUint(seq, 10)
+		p.Created = time.Now().UTC()
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(seqKey(seq), data)
+	})
+	if err != nil {
+		return api.Passenger{}, fmt.Errorf("save passenger: %w", err)
+	}
+	return p, nil
+}
+
+// Passenger implements Store.
+func (b *Bolt) Passenger(id string) (api.Passenger, error) {
+	var p api.Passenger
+	err := b.db.View(func(tx *bolt.Tx) error {
+		_, err := getPassenger(tx, id, &p)
+		return err
+	})
+	return p, err
+}
+
+// Passengers implements Store.
+func (b *Bolt) Passengers() ([]api.Passenger, error) {
+	var all []api.Passenger
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(passengersBucket).ForEach(func(_, v []byte) error {
+			var p api.Passenger
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			all = append(all, p)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list passengers: %w", err)
+	}
+	return all, nil
+}
+
+// AppendSegments implements Store.
+func (b *Bolt) AppendSegments(id string, segments []api.Flight, check func([]api.Flight) error) (api.Passenger, error) {
+	var p api.Passenger
+	var checkErr error
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		seq, err := getPassenger(tx, id, &p)
+		if err != nil {
+			return err
+		}
+		p.Segments = append(p.Segments, segments...)
+		if checkErr = check(p.Segments); checkErr != nil {
+			return checkErr
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(passengersBucket).Put(seqKey(seq), data)
+	})
+	switch {
+	case checkErr != nil, errors.Is(err, ErrNotFound):
+		return api.Passenger{}, err
+	case err != nil:
+		return api.Passenger{}, fmt.Errorf("append segments: %w", err)
+	}
+	return p, nil
+}
+
+// getPassenger reads passenger id into p and returns its key sequence, or
+// ErrNotFound.
+func getPassenger(tx *bolt.Tx, id string, p *api.Passenger) (uint64, error) {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, ErrNotFound
+	}
+	data := tx.Bucket(passengersBucket).Get(seqKey(seq))
+	if data == nil {
+		return 0, ErrNotFound
+	}
+	return seq, json.Unmarshal(data, p)
+}
+
+// Close implements Store.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// seqKey encodes an ID sequence number as a bucket key.
+func seqKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
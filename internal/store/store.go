@@ -0,0 +1,218 @@
+// Package store persists saved itineraries and passengers, either in memory
+// or in an embedded bbolt database file.
+package store
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// ErrNotFound is returned for an unknown itinerary or passenger ID.
+var ErrNotFound = errors.New("not found")
+
+// Store saves itineraries and passengers and reads them back. IDs are
+// assigned by the store from an increasing sequence per kind, so List
+// returns itineraries in the order they were created. Implementations are
+// safe for concurrent use.
+type Store interface {
+	Itineraries
+	Passengers
+	// Close releases the store's resources.
+	Close() error
+}
+
+// Itineraries is the itinerary half of a Store. Every version of an
+// itinerary is kept; Get and List return the current one.
+type Itineraries interface {
+	// Create assigns it an ID and creation time, saves it as version 1 and
+	// returns the saved record.
+	Create(it api.StoredItinerary) (api.StoredItinerary, error)
+	// Get returns the current version of the itinerary with the given ID,
+	// or ErrNotFound.
+	Get(id string) (api.StoredItinerary, error)
+	// List returns up to limit itineraries starting at offset, and the total
+	// number stored.
+	List(offset, limit int) ([]api.StoredItinerary, int, error)
+	// Update calls change with the current version of itinerary id while
+	// the itinerary is locked against other updates, and saves its result
+	// as the next version, keeping ID and Created. When change returns an
+	// error nothing is saved and that error is returned unwrapped.
+	Update(id string, change func(api.StoredItinerary) (api.StoredItinerary, error)) (api.StoredItinerary, error)
+	// Versions returns every version of itinerary id, oldest first, or
+	// ErrNotFound.
+	Versions(id string) ([]api.StoredItinerary, error)
+}
+
+// Passengers is the passenger half of a Store.
+type Passengers interface {
+	// CreatePassenger assigns p an ID and creation time, saves it and
+	// returns the saved record.
+	CreatePassenger(p api.Passenger) (api.Passenger, error)
+	// Passenger returns the passenger with the given ID, or ErrNotFound.
+	Passenger(id string) (api.Passenger, error)
+	// Passengers returns every passenger in creation order.
+	Passengers() ([]api.Passenger, error)
+	// AppendSegments adds segments to passenger id. check is called with
+	// the combined segments while the passenger is locked against other
+	// appends; when it returns an error nothing is saved and that error is
+	// returned unwrapped.
+	AppendSegments(id string, segments []api.Flight, check func([]api.Flight) error) (api.Passenger, error)
+}
+
+// Memory is a Store that keeps itineraries and passengers in process memory;
+// they are lost when the process exits.
+type Memory struct {
+	mu sync.RWMutex
+	// itineraries holds the versions of each itinerary, oldest first.
+	itineraries [][]api.StoredItinerary
+	passengers  []api.Passenger
+}
+
+// NewMemory returns an empty in-memory Store.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Create implements Store.
+func (m *Memory) Create(it api.StoredItinerary) (api.StoredItinerary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	it.ID = strconv.Itoa(len(m.itineraries) + 1)
+	it.Created = time.Now().UTC()
+	it.Version = 1
+	it.Modified = it.Created
+	m.itineraries = append(m.itineraries, []api.StoredItinerary{it})
+	return it, nil
+}
+
+// Get implements Store.
+func (m *Memory) Get(id string) (api.StoredItinerary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i, ok := seqIndex(id, len(m.itineraries))
+	if !ok {
+		return api.StoredItinerary{}, ErrNotFound
+	}
+	versions := m.itineraries[i]
+	return versions[len(versions)-1], nil
+}
+
+// List implements Store.
+func (m *Memory) List(offset, limit int) ([]api.StoredItinerary, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	total := len(m.itineraries)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	page := make([]api.StoredItinerary, 0, end-start)
+	for _, versions := range m.itineraries[start:end] {
+		page = append(page, versions[len(versions)-1])
+	}
+	return page, total, nil
+}
+
+// Update implements Store.
+func (m *Memory) Update(id string, change func(api.StoredItinerary) (api.StoredItinerary, error)) (api.StoredItinerary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := seqIndex(id, len(m.itineraries))
+	if !ok {
+		return api.StoredItinerary{}, ErrNotFound
+	}
+	current := m.itineraries[i][len(m.itineraries[i])-1]
+	next, err := change(current)
+	if err != nil {
+		return api.StoredItinerary{}, err
+	}
+	next = nextVersion(current, next)
+	m.itineraries[i] = append(m.itineraries[i], next)
+	return next, nil
+}
+
+// Versions implements Store.
+func (m *Memory) Versions(id string) ([]api.StoredItinerary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i, ok := seqIndex(id, len(m.itineraries))
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(m.itineraries[i]), nil
+}
+
+// CreatePassenger implements Store.
+func (m *Memory) CreatePassenger(p api.Passenger) (api.Passenger, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.ID = strconv.Itoa(len(m.passengers) + 1)
+	p.Created = time.Now().UTC()
+	m.passengers = append(m.passengers, p)
+	return p, nil
+}
+
+// Passenger implements Store.
+func (m *Memory) Passenger(id string) (api.Passenger, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i, ok := m.passengerIndex(id)
+	if !ok {
+		return api.Passenger{}, ErrNotFound
+	}
+	return m.passengers[i], nil
+}
+
+// Passengers implements Store.
+func (m *Memory) Passengers() ([]api.Passenger, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.passengers), nil
+}
+
+// AppendSegments implements Store.
+func (m *Memory) AppendSegments(id string, segments []api.Flight, check func([]api.Flight) error) (api.Passenger, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := m.passengerIndex(id)
+	if !ok {
+		return api.Passenger{}, ErrNotFound
+	}
+	combined := slices.Concat(m.passengers[i].Segments, segments)
+	if err := check(combined); err != nil {
+		return api.Passenger{}, err
+	}
+	m.passengers[i].Segments = combined
+	return m.passengers[i], nil
+}
+
+// passengerIndex maps a passenger ID to its position in m.passengers.
+func (m *Memory) passengerIndex(id string) (int, bool) {
+	return seqIndex(id, len(m.passengers))
+}
+
+// seqIndex maps an ID to its position in a slice of n records.
+func seqIndex(id string, n int) (int, bool) {
+	seq, err := strconv.Atoi(id)
+	if err != nil || seq < 1 || seq > n {
+		return 0, false
+	}
+	return seq - 1, true
+}
+
+// nextVersion stamps next as the version that follows current.
+func nextVersion(current, next api.StoredItinerary) api.StoredItinerary {
+	next.ID = current.ID
+	next.Created = current.Created
+	next.Version = current.Version + 1
+	next.Modified = time.Now().UTC()
+	return next
+}
+
+// Close implements Store; it has nothing to release.
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// backends returns a fresh instance of every Store implementation.
+func backends(t *testing.T) map[string]Store {
+	t.Helper()
+	b, err := OpenBolt(filepath.Join(t.TempDir(), "store.db"))
+	if err != nil {
+		t.Fatalf("OpenBolt: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return map[string]Store{"memory": NewMemory(), "bolt": b}
+}
+
+func TestStore(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			var ids []string
+			for _, path := range [][]string{{"SFO", "EWR"}, {"JFK", "LHR"}, {"ATL", "ORD"}} {
+				it, err := s.Create(api.StoredItinerary{
+					Segments: []api.Flight{{Start: path[0], End: path[1], Number: "DL1"}},
+					Start:    path[0],
+					End:      path[1],
+					Path:     path,
+				})
+				if err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if it.ID == "" || it.Created.IsZero() {
+					t.Errorf("Create returned %+v, want ID and Created set", it)
+				}
+				ids = append(ids, it.ID)
+			}
+
+			got, err := s.Get(ids[1])
+			if err != nil || got.Start != "JFK" || got.Segments[0].Number != "DL1" || !slices.Equal(got.Path, []string{"JFK", "LHR"}) {
+				t.Errorf("Get(%s) = %+v, %v", ids[1], got, err)
+			}
+			for _, id := range []string{"0", "99", "abc", ""} {
+				if _, err := s.Get(id); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get(%q) error = %v, want ErrNotFound", id, err)
+				}
+			}
+
+			tests := []struct {
+				offset, limit int
+				want          []string
+			}{
+				{0, 2, []string{"SFO", "JFK"}},
+				{2, 2, []string{"ATL"}},
+				{1, 10, []string{"JFK", "ATL"}},
+				{5, 2, []string{}},
+			}
+			for _, tt := range tests {
+				page, total, err := s.List(tt.offset, tt.limit)
+				if err != nil || total != 3 {
+					t.Fatalf("List(%d, %d) total = %d, %v", tt.offset, tt.limit, total, err)
+				}
+				starts := make([]string, 0, len(page))
+				for _, it := range page {
+					starts = append(starts, it.Start)
+				}
+				if !slices.Equal(starts, tt.want) {
+					t.Errorf("List(%d, %d) = %v, want %v", tt.offset, tt.limit, starts, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreVersions(t *testing.T) {
+	errRejected := errors.New("rejected")
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			created, err := s.Create(api.StoredItinerary{Start: "SFO", End: "ATL", Path: []string{"SFO", "ATL"}})
+			if err != nil || created.Version != 1 || !created.Modified.Equal(created.Created) {
+				t.Fatalf("Create = %+v, %v, want version 1", created, err)
+			}
+
+			updated, err := s.Update(created.ID, func(cur api.StoredItinerary) (api.StoredItinerary, error) {
+				cur.End = "EWR"
+				cur.ID, cur.Version = "forged", 42
+				return cur, nil
+			})
+			if err != nil || updated.ID != created.ID || updated.Version != 2 || updated.End != "EWR" || !updated.Created.Equal(created.Created) {
+				t.Fatalf("Update = %+v, %v, want version 2 ending at EWR", updated, err)
+			}
+			reject := func(api.StoredItinerary) (api.StoredItinerary, error) { return api.StoredItinerary{}, errRejected }
+			if _, err := s.Update(created.ID, reject); !errors.Is(err, errRejected) {
+				t.Errorf("rejected Update error = %v, want the change error", err)
+			}
+
+			if got, err := s.Get(created.ID); err != nil || got.Version != 2 || got.End != "EWR" {
+				t.Errorf("Get = %+v, %v, want version 2", got, err)
+			}
+			if page, _, err := s.List(0, 10); err != nil || len(page) != 1 || page[0].Version != 2 {
+				t.Errorf("List = %+v, %v, want version 2 only", page, err)
+			}
+			versions, err := s.Versions(created.ID)
+			if err != nil || len(versions) != 2 || versions[0].End != "ATL" || versions[1].End != "EWR" || versions[1].Version != 2 {
+				t.Errorf("Versions = %+v, %v, want ATL then EWR", versions, err)
+			}
+
+			if _, err := s.Versions("99"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Versions(99) error = %v, want ErrNotFound", err)
+			}
+			if _, err := s.Update("99", reject); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Update(99) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestBoltPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	b, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("OpenBolt: %v", err)
+	}
+	saved, err := b.Create(api.StoredItinerary{Start: "SFO", End: "EWR", Path: []string{"SFO", "EWR"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	b, err = OpenBolt(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer b.Close()
+	if got, err := b.Get(saved.ID); err != nil || got.Start != "SFO" || !got.Created.Equal(saved.Created) {
+		t.Errorf("Get after reopen = %+v, %v, want %+v", got, err, saved)
+	}
+	if next, _ := b.Create(api.StoredItinerary{}); next.ID == saved.ID {
+		t.Errorf("ID %s reused after reopen", next.ID)
+	}
+}
+
+// TestBoltAddsVersionHistory reopens a file whose itinerary was saved
+// before versions were kept and checks it becomes version 1.
+func TestBoltAddsVersionHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	b, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("OpenBolt: %v", err)
+	}
+	legacy, err := json.Marshal(api.StoredItinerary{ID: "1", Created: time.Now().UTC(), Start: "SFO", End: "EWR"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.Bucket(itinerariesBucket).NextSequence(); err != nil {
+			return err
+		}
+		return tx.Bucket(itinerariesBucket).Put(seqKey(1), legacy)
+	})
+	if err != nil {
+		t.Fatalf("write legacy itinerary: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	b, err = OpenBolt(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer b.Close()
+	versions, err := b.Versions("1")
+	if err != nil || len(versions) != 1 || versions[0].Version != 1 || versions[0].Start != "SFO" {
+		t.Fatalf("Versions = %+v, %v, want the legacy record as version 1", versions, err)
+	}
+	updated, err := b.Update("1", func(it api.StoredItinerary) (api.StoredItinerary, error) { return it, nil })
+	if err != nil || updated.Version != 2 {
+		t.Errorf("Update = %+v, %v, want version 2", updated, err)
+	}
+}
+
+func TestStorePassengers(t *testing.T) {
+	errRejected := errors.New("rejected")
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			p, err := s.CreatePassenger(api.Passenger{Name: "Alice"})
+			if err != nil || p.ID == "" || p.Created.IsZero() {
+				t.Fatalf("CreatePassenger = %+v, %v", p, err)
+			}
+
+			var checked []api.Flight
+			accept := func(all []api.Flight) error { checked = all; return nil }
+			if _, err := s.AppendSegments(p.ID, []api.Flight{{Start: "SFO", End: "ATL"}}, accept); err != nil {
+				t.Fatalf("AppendSegments: %v", err)
+			}
+			got, err := s.AppendSegments(p.ID, []api.Flight{{Start: "ATL", End: "EWR"}}, accept)
+			if err != nil || len(got.Segments) != 2 || len(checked) != 2 || got.Name != "Alice" {
+				t.Fatalf("second AppendSegments = %+v, %v (checked %v)", got, err, checked)
+			}
+
+			reject := func([]api.Flight) error { return errRejected }
+			if _, err := s.AppendSegments(p.ID, []api.Flight{{Start: "EWR", End: "SFO"}}, reject); !errors.Is(err, errRejected) {
+				t.Errorf("rejected AppendSegments error = %v, want the check error", err)
+			}
+			if got, _ := s.Passenger(p.ID); len(got.Segments) != 2 || got.Segments[1].End != "EWR" {
+				t.Errorf("Passenger after rejected append = %+v, want the 2 accepted segments", got)
+			}
+
+			if _, err := s.CreatePassenger(api.Passenger{Name: "Bob"}); err != nil {
+				t.Fatalf("CreatePassenger: %v", err)
+			}
+			all, err := s.Passengers()
+			if err != nil || len(all) != 2 || all[0].Name != "Alice" || len(all[0].Segments) != 2 || all[1].Name != "Bob" {
+				t.Errorf("Passengers() = %+v, %v, want Alice with 2 segments then Bob", all, err)
+			}
+
+			if _, err := s.Passenger("99"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Passenger(99) error = %v, want ErrNotFound", err)
+			}
+			if _, err := s.AppendSegments("x", nil, accept); !errors.Is(err, ErrNotFound) {
+				t.Errorf("AppendSegments(x) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// Package webhook delivers JSON payloads to callback URLs, signing each
+// request with HMAC-SHA256 and retrying failures with exponential backoff.
+// Callback URLs come from API clients, so a Sender refuses to connect to
+// loopback, private, link-local and other non-public addresses unless the
+// operator allows the host explicitly.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the
+// request body, keyed with the sender's secret.
+const SignatureHeader = "X-Signature-256"
+
+// Defaults for New used when no configuration is given.
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = time.Second
+
+	// requestTimeout bounds each delivery attempt.
+	requestTimeout = 10 * time.Second
+)
+
+// ErrInvalidURL is returned by ValidateURL for a callback URL that is not an
+// absolute http or https URL.
+var ErrInvalidURL = errors.New("callback must be an absolute http or https URL")
+
+// ErrForbiddenHost is returned by CheckURL, and recorded for a delivery
+// attempt, when a callback host is or resolves to a non-public address and is
+// not in the sender's allowed hosts.
+var ErrForbiddenHost = errors.New("callback host is not allowed")
+
+// Sender posts signed payloads to callback URLs. A Sender is safe for
+// concurrent use.
+type Sender struct {
+	client      *http.Client
+	secret      []byte
+	maxAttempts int
+	backoff     time.Duration
+	allowed     map[string]bool
+}
+
+// New returns a Sender that signs with secret and makes at most maxAttempts
+// attempts per delivery, waiting backoff before the first retry and doubling
+// the wait before each further one. Values below 1 fall back to the defaults.
+// allowedHosts lists host names or IP addresses, matched case-insensitively
+// against the callback URL's host, that may be reached even though they are
+// or resolve to non-public addresses; empty entries are ignored.
+func New(secret string, maxAttempts int, backoff time.Duration, allowedHosts []string) *Sender {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	s := &Sender{
+		secret:      []byte(secret),
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		allowed:     make(map[string]bool, len(allowedHosts)),
+	}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			s.allowed[host] = true
+		}
+	}
+	// No Proxy: behind a proxy the dialer would only see the proxy's
+	// address, not the callback host's.
+	s.client = &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext:         s.dial,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: requestTimeout,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return s
+}
+
+// Sign returns the SignatureHeader value for body under secret. Receivers
+// verify a delivery by computing the same value and comparing it with
+// hmac.Equal.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateURL reports whether raw is an absolute http or https URL.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, raw)
+	}
+	return nil
+}
+
+// CheckURL reports whether s may deliver to raw: it must pass ValidateURL,
+// and unless its host is allowed it must not be "localhost" or a non-public
+// IP address. Host names are not resolved here; the addresses they resolve
+// to are checked again on every connection.
+func (s *Sender) CheckURL(raw string) error {
+	if err := ValidateURL(raw); err != nil {
+		return err
+	}
+	u, _ := url.Parse(raw)
+	host := strings.ToLower(u.Hostname())
+	if s.allowed[host] {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+	return nil
+}
+
+// dial connects to addr, refusing non-public addresses unless addr's host is
+// allowed. The check runs on the resolved address, so a host name cannot
+// smuggle in a private one.
+func (s *Sender) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if host, _, err := net.SplitHostPort(addr); err != nil || !s.allowed[strings.ToLower(host)] {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil || !public(ap.Addr()) {
+				return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenHost, addr, address)
+			}
+			return nil
+		}
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// specialPurpose lists the ranges of the IANA IPv4 and IPv6 Special-Purpose
+// Address Registries that are not globally reachable, plus the deprecated
+// IPv4-compatible and site-local IPv6 ranges, which public refuses on top of
+// what IsGlobalUnicast and IsPrivate catch.
+var specialPurpose = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // shared address space (CGNAT)
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, limited broadcast
+	netip.MustParsePrefix("::/96"),           // IPv4-compatible (deprecated)
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use IPv4/IPv6 translation
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("3fff::/20"),       // documentation
+	netip.MustParsePrefix("5f00::/16"),       // segment routing SIDs
+	netip.MustParsePrefix("fec0::/10"),       // site-local (deprecated)
+}
+
+// Prefixes of IPv6 addresses that carry an IPv4 address a gateway forwards
+// to: NAT64 (RFC 6052) in the last four bytes, 6to4 (RFC 3056) in bytes 2-5.
+var (
+	nat64     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour = netip.MustParsePrefix("2002::/16")
+)
+
+// public reports whether ip is a publicly routable unicast address.
+// IsGlobalUnicast already excludes loopback, link-local (169.254.0.0/16,
+// fe80::/10), multicast and unspecified addresses, and IsPrivate the RFC 1918
+// and RFC 4193 ranges; specialPurpose covers the rest. A NAT64 or 6to4
+// address is public only if the IPv4 address it embeds is.
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range specialPurpose {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	b := ip.As16()
+	switch {
+	case nat64.Contains(ip):
+		return public(netip.AddrFrom4([4]byte(b[12:16])))
+	case sixToFour.Contains(ip):
+		return public(netip.AddrFrom4([4]byte(b[2:6])))
+	}
+	return true
+}
+
+// Deliver POSTs payload as JSON to target until the receiver answers with a
+// 2xx status or the attempts run out, calling record after every attempt. It
+// reports whether the payload was delivered. Canceling ctx stops further
+// retries.
+func (s *Sender) Deliver(ctx context.Context, target string, payload any, record func(api.DeliveryAttempt)) bool {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		record(api.DeliveryAttempt{Attempt: 1, At: time.Now().UTC(), Error: err.Error()})
+		return false
+	}
+	signature := Sign(s.secret, body)
+
+	wait := s.backoff
+	for n := 1; ; n++ {
+		attempt := s.post(ctx, target, body, signature)
+		attempt.Attempt = n
+		record(attempt)
+		if attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+			return true
+		}
+		if n == s.maxAttempts {
+			return false
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false
+		}
+		wait *= 2
+	}
+}
+
+// post makes a single delivery attempt.
+func (s *Sender) post(ctx context.Context, target string, body []byte, signature string) api.DeliveryAttempt {
+	attempt := api.DeliveryAttempt{At: time.Now().UTC()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	resp.Body.Close()
+	attempt.StatusCode = resp.StatusCode
+	return attempt
+}
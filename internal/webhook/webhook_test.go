@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AndriyKalashnykov/flight-path/pkg/api"
+)
+
+func TestDeliverSignsAndRetries(t *testing.T) {
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !hmac.Equal([]byte(r.Header.Get(SignatureHeader)), []byte(Sign([]byte("secret"), body))) {
+			t.Errorf("bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		bodies = append(bodies, body)
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer receiver.Close()
+
+	var attempts []api.DeliveryAttempt
+	record := func(a api.DeliveryAttempt) { attempts = append(attempts, a) }
+	s := New("secret", 4, 10*time.Millisecond, []string{"127.0.0.1"})
+	if !s.Deliver(context.Background(), receiver.URL, map[string]string{"ID": "job"}, record) {
+		t.Fatalf("Deliver = false, attempts %+v", attempts)
+	}
+	if len(attempts) != 3 || attempts[2].Attempt != 3 || attempts[2].StatusCode != http.StatusOK {
+		t.Fatalf("attempts = %+v, want two 500s then a 200", attempts)
+	}
+	if string(bodies[0]) != `{"ID":"job"}` {
+		t.Errorf("body = %s", bodies[0])
+	}
+	// Backoff doubles: 10ms before the second attempt, 20ms before the third.
+	if gap := attempts[2].At.Sub(attempts[1].At); gap < 20*time.Millisecond {
+		t.Errorf("second retry after %v, want at least 20ms", gap)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	receiver.Close()
+
+	var attempts []api.DeliveryAttempt
+	s := New("secret", 2, time.Millisecond, []string{"127.0.0.1"})
+	if s.Deliver(context.Background(), receiver.URL, "payload", func(a api.DeliveryAttempt) { attempts = append(attempts, a) }) {
+		t.Fatal("Deliver to a closed server = true")
+	}
+	if len(attempts) != 2 || attempts[1].Error == "" {
+		t.Errorf("attempts = %+v, want 2 failed attempts", attempts)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for _, raw := range []string{"http://localhost:9000/hook", "https://example.com/a?b=c"} {
+		if err := ValidateURL(raw); err != nil {
+			t.Errorf("ValidateURL(%q) = %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"", "example.com/hook", "ftp://example.com", "https://", "::"} {
+		if err := ValidateURL(raw); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("ValidateURL(%q) = %v, want ErrInvalidURL", raw, err)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	s := New("secret", 1, time.Millisecond, []string{" Hooks.internal ", "10.0.0.7", ""})
+	for _, raw := range []string{
+		"https://example.com/hook",
+		"http://93.184.215.14/hook",
+		"http://hooks.internal/a",
+		"http://10.0.0.7:8080/a",
+		"http://[2606:4700:4700::1111]/hook",
+		"http://[64:ff9b::5db8:d70e]/hook",
+		"http://[2002:5db8:d70e::1]/hook",
+	} {
+		if err := s.CheckURL(raw); err != nil {
+			t.Errorf("CheckURL(%q) = %v", raw, err)
+		}
+	}
+	for _, raw := range []string{
+		"http://localhost:9000/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://10.0.0.8/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://100.64.0.1/hook",
+		"http://100.100.100.200/latest/meta-data",
+		"http://0.1.2.3/hook",
+		"http://198.18.0.1/hook",
+		"http://192.0.2.1/hook",
+		"http://240.0.0.1/hook",
+		"http://[64:ff9b::a9fe:a9fe]/latest/meta-data",
+		"http://[64:ff9b::a00:1]/hook",
+		"http://[64:ff9b:1::1]/hook",
+		"http://[2002:a9fe:a9fe::1]/hook",
+		"http://[2002:7f00:1::]/hook",
+		"http://[2001:db8::1]/hook",
+		"http://[::a9fe:a9fe]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if err := s.CheckURL(raw); !errors.Is(err, ErrForbiddenHost) {
+			t.Errorf("CheckURL(%q) = %v, want ErrForbiddenHost", raw, err)
+		}
+	}
+	if err := s.CheckURL("ftp://example.com"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("CheckURL(ftp) = %v, want ErrInvalidURL", err)
+	}
+}
+
+func TestDeliverRefusesResolvedLoopback(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls.Add(1) }))
+	defer receiver.Close()
+	// "localhost" passes no allowlist and resolves to loopback, so the
+	// connection itself must be refused.
+	target := strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)
+
+	var attempts []api.DeliveryAttempt
+	s := New("secret", 1, time.Millisecond, nil)
+	if s.Deliver(context.Background(), target, "payload", func(a api.DeliveryAttempt) { attempts = append(attempts, a) }) {
+		t.Fatal("Deliver to a loopback receiver = true")
+	}
+	if calls.Load() != 0 || len(attempts) != 1 || !strings.Contains(attempts[0].Error, ErrForbiddenHost.Error()) {
+		t.Errorf("calls = %d, attempts = %+v, want one refused attempt", calls.Load(), attempts)
+	}
+}
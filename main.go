@@ -36,7 +36,10 @@ func main() {
 		log.Fatalf("failed to load environment variables: %v", err)
 	}
 
-	e := app.New()
+	e, err := app.New()
+	if err != nil {
+		log.Fatalf("failed to start: %v", err)
+	}
 	if err := e.Start(":" + app.Port()); err != nil {
 		log.Fatal(err)
 	}
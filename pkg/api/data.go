@@ -1,10 +1,369 @@
 // Package api contains API data structures and models.
 package api
 
-// Flight represents a flight segment with a start and end airport.
+import "time"
+
+// Flight represents a flight segment with a start and end airport. Number
+// (the flight number, e.g. "DL123"), Departure, Arrival and Source (the
+// system the record came from, e.g. "airline") are optional; the zero value
+// means unknown and is omitted from JSON.
 type Flight struct {
+	Start     string
+	End       string
+	Number    string    `json:",omitempty"`
+	Departure time.Time `json:",omitzero"`
+	Arrival   time.Time `json:",omitzero"`
+	Source    string    `json:",omitempty"`
+}
+
+// CalculateRequest is the object form of the POST /calculate body, accepted
+// alongside the legacy [][]string array. Version defaults to 1, the only
+// version defined so far.
+type CalculateRequest struct {
+	Version  int       `json:"version,omitempty"`
+	Segments []Segment `json:"segments"`
+	Options  Options   `json:"options,omitzero"`
+}
+
+// Segment is one flight segment in a CalculateRequest. Departs and Arrives
+// are optional RFC 3339 timestamps. Source tags the record with the system
+// it came from; segments reported by several sources are merged before
+// solving (see Merge).
+type Segment struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Flight  string `json:"flight,omitempty"`
+	Departs string `json:"departs,omitempty"`
+	Arrives string `json:"arrives,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// Options carries the solver settings of a CalculateRequest. They mirror the
+// query parameters of the same name, which take precedence when both are set.
+// Sources ranks record sources from most to least trusted for the merge.
+type Options struct {
+	Mode    string   `json:"mode,omitempty"`
+	Anchor  string   `json:"anchor,omitempty"`
+	Cabin   string   `json:"cabin,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+// Itinerary is the ordered reconstruction of a single connected path. Path
+// lists every airport in travel order (len(Legs)+1 entries) and Legs lists the
+// segments in the order they are flown. Rewrites lists the input airport codes
+// that were normalized before solving, and Merges the source-tagged records
+// that were de-duplicated.
+type Itinerary struct {
+	Path     []string
+	Legs     []Flight
+	Rewrites []Rewrite `json:",omitempty"`
+	Merges   []Merge   `json:",omitempty"`
+}
+
+// CalculateResponse answers a POST /calculate request made with the
+// CalculateRequest object body; legacy array requests keep receiving the
+// bare [start, end] array.
+type CalculateResponse struct {
+	Start    string
+	End      string
+	Rewrites []Rewrite `json:",omitempty"`
+	Merges   []Merge   `json:",omitempty"`
+}
+
+// Rewrite records an airport code that was normalized before solving: the
+// Field ("Start" or "End") of the segment at Index changed From one spelling
+// To its canonical IATA code.
+type Rewrite struct {
+	Index int
+	Field string
+	From  string
+	To    string
+}
+
+// Merge records input segments that several sources reported for the same
+// leg and that were solved as one. Kept is the input index of the record
+// from the highest-priority source, whose values win; Merged lists the
+// records that agreed with it (they may fill a flight number or times it
+// lacked) and Discarded those that contradicted it. Segment is the leg's
+// position among the merged segments, which later error Indexes refer to.
+type Merge struct {
+	Segment   int
+	Kept      int
+	Source    string
+	Merged    []int `json:",omitempty"`
+	Discarded []int `json:",omitempty"`
+}
+
+// Component is one connected group of segments from a payload that holds
+// several separate trips. Indexes lists the payload positions of its
+// segments. When the group is a valid itinerary, Start, End and Path describe
+// it; otherwise Error explains why it could not be solved on its own.
+type Component struct {
+	Start   string
+	End     string
+	Path    []string
+	Indexes []int
+	Error   string `json:",omitempty"`
+}
+
+// GapAnalysis describes how a broken itinerary could be repaired. Fragments
+// lists each partial path found in the input, in travel order. Ends and
+// Starts list, sorted, the airports where fragments end and start: a segment
+// from any end to the start of another fragment is a candidate bridge.
+// Bridges is the smallest set of such segments (one fewer than the number of
+// fragments) that joins all fragments into a single path.
+type GapAnalysis struct {
+	Fragments [][]string
+	Ends      []string
+	Starts    []string
+	Bridges   []Flight
+}
+
+// BatchResult is the outcome for one passenger of a batch request. On success
+// Start, End and Path describe the itinerary; on failure Error holds the
+// message and Details the diagnostic fields (Index, Indexes, Airport, ...) that
+// a single POST /calculate would return alongside it.
+type BatchResult struct {
+	Start   string         `json:",omitempty"`
+	End     string         `json:",omitempty"`
+	Path    []string       `json:",omitempty"`
+	Error   string         `json:",omitempty"`
+	Details map[string]any `json:",omitempty"`
+}
+
+// BatchResponse holds one BatchResult per passenger ID, with the number of
+// IDs that Succeeded and Failed.
+type BatchResponse struct {
+	Results   map[string]BatchResult
+	Succeeded int
+	Failed    int
+}
+
+// Job is the state of an asynchronous calculation submitted to POST /jobs, or
+// to POST /calculate/batch with a callback. Status is one of queued, running,
+// succeeded, failed or canceled. Started, Finished and Expires are set as the
+// job progresses; once Expires passes the job is forgotten. When the job has
+// finished, Result holds a single itinerary's outcome and Batch a batch's.
+// Callback is set when the submission asked for a webhook.
+type Job struct {
+	ID        string
+	Status    string
+	Submitted time.Time
+	Started   time.Time      `json:",omitzero"`
+	Finished  time.Time      `json:",omitzero"`
+	Expires   time.Time      `json:",omitzero"`
+	Result    *BatchResult   `json:",omitempty"`
+	Batch     *BatchResponse `json:",omitempty"`
+	Callback  *Callback      `json:",omitempty"`
+}
+
+// Callback tracks the webhook delivery of a finished job to URL. Status is
+// pending until an attempt succeeds (delivered) or the last retry fails
+// (failed); Attempts records every try in order.
+type Callback struct {
+	URL      string
+	Status   string
+	Attempts []DeliveryAttempt
+}
+
+// DeliveryAttempt is one POST of a job to its callback URL: the receiver's
+// StatusCode, or Error when no response was received.
+type DeliveryAttempt struct {
+	Attempt    int
+	At         time.Time
+	StatusCode int    `json:",omitempty"`
+	Error      string `json:",omitempty"`
+}
+
+// StoredItinerary is an itinerary saved with POST /itineraries: the
+// normalized input Segments, the Options they were solved with, and the
+// computed Start, End and Path. ID, Created, Version and Modified are
+// assigned by the store; Version starts at 1 and each PATCH saves the next
+// one, stamped with its Modified time.
+type StoredItinerary struct {
+	ID       string
+	Created  time.Time
+	Version  int
+	Modified time.Time
+	Segments []Flight
+	Options  Options `json:",omitzero"`
+	Start    string
+	End      string
+	Path     []string
+}
+
+// ItineraryPage is one page of GET /itineraries: up to Limit itineraries
+// starting at Offset, in the order they were saved, out of Total.
+type ItineraryPage struct {
+	Itineraries []StoredItinerary
+	Offset      int
+	Limit       int
+	Total       int
+}
+
+// ItineraryVersions is the history of a saved itinerary, every version from
+// the first to the current one.
+type ItineraryVersions struct {
+	ID       string
+	Versions []StoredItinerary
+}
+
+// PassengerRequest is the POST /passengers body. Name is optional; segments
+// are appended afterwards with POST /passengers/{id}/segments.
+type PassengerRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// Passenger is a traveller whose timestamped flight Segments are collected
+// over time, in the order they were appended. Every segment carries both a
+// Departure and an Arrival, and together they always form one valid timed
+// itinerary.
+type Passenger struct {
+	ID       string
+	Name     string `json:",omitempty"`
+	Created  time.Time
+	Segments []Flight
+}
+
+// Location answers where a passenger was at instant At. Status is
+// "in_flight" (Segment is the leg being flown), "at_airport" (Airport is
+// where they waited between two legs), "not_departed" (Airport is the
+// origin of the first leg), "arrived" (Airport is the final destination) or
+// "unknown" (no segments recorded yet).
+type Location struct {
+	Passenger string
+	At        time.Time
+	Status    string
+	Airport   string  `json:",omitempty"`
+	Segment   *Flight `json:",omitempty"`
+}
+
+// Session is the state of an itinerary assembled one segment at a time with
+// POST /sessions/{id}/segments. Status is "empty", "valid" (Start and End are
+// set), "circular" (every airport is both a source and a destination) or
+// "disconnected" (several loose ends, listed in StartCandidates and
+// EndCandidates, or a loop beside the path). Segments counts the accepted
+// segments and Components the separate groups of airports they form.
+// Expires is when the session will be dropped unless it is used again.
+type Session struct {
+	ID              string
+	Created         time.Time
+	Expires         time.Time
+	Segments        int
+	Status          string
+	Start           string   `json:",omitempty"`
+	End             string   `json:",omitempty"`
+	StartCandidates []string `json:",omitempty"`
+	EndCandidates   []string `json:",omitempty"`
+	Components      int
+}
+
+// ContactTrace lists every passenger linked to Passenger: they flew the same
+// flight number on the same date, or were at the same airport within Window
+// of each other. Contacts are ordered by passenger ID.
+type ContactTrace struct {
+	Passenger string
+	Window    string
+	Contacts  []Contact
+}
+
+// Contact is one passenger linked to a traced passenger. Flights are the
+// contact's legs sharing a flight number and date with the traced passenger;
+// Stays are the contact's airport stays that overlap one of theirs.
+type Contact struct {
+	Passenger string
+	Flights   []Flight `json:",omitempty"`
+	Stays     []Stay   `json:",omitempty"`
+}
+
+// Stay is the span a passenger spent at Airport: from landing to the next
+// departure between two legs, or the single instant of departure from the
+// origin or of arrival at the final destination. A connection longer than a
+// day is taken as leaving the airport and becomes two instant stays.
+type Stay struct {
+	Airport string
+	From    time.Time
+	To      time.Time
+}
+
+// StreamResult is one line of a streamed batch response: the BatchResult for
+// the input on Line (1-based) of the request body.
+type StreamResult struct {
+	Line int
+	BatchResult
+}
+
+// Distance is a length expressed in kilometres, statute miles and nautical
+// miles, each rounded to one decimal place.
+type Distance struct {
+	Km float64
+	Mi float64
+	Nm float64
+}
+
+// LegSummary describes one flown segment: its great-circle Distance, an
+// EstimatedMinutes block time derived from that distance and, when both
+// timestamps are known, the ScheduledMinutes between departure and arrival.
+type LegSummary struct {
+	Start            string
+	End              string
+	Distance         Distance
+	EstimatedMinutes int
+	ScheduledMinutes int `json:",omitempty"`
+}
+
+// Summary totals the distances and flight times of an itinerary. Direct is
+// the great-circle distance from origin to final destination and DetourRatio
+// is Total.Km / Direct.Km (omitted when the itinerary ends where it began).
+// ScheduledMinutes sums the legs whose scheduled time is known. Emissions is
+// the CO2 estimate for the same legs.
+type Summary struct {
+	Legs             []LegSummary
+	Total            Distance
+	Direct           Distance
+	DetourRatio      float64 `json:",omitempty"`
+	EstimatedMinutes int
+	ScheduledMinutes int `json:",omitempty"`
+	Emissions        Emissions
+}
+
+// LegEmissions is the CO2 estimate for one leg: its great-circle Km, the
+// haul Band ("short", "medium" or "long") that selected the emission factor,
+// and the resulting KgCO2 per passenger.
+type LegEmissions struct {
 	Start string
 	End   string
+	Km    float64
+	Band  string
+	KgCO2 float64
+}
+
+// Emissions is the per-passenger CO2 estimate of an itinerary flown in Cabin
+// ("economy", "premium_economy", "business" or "first").
+type Emissions struct {
+	Cabin      string
+	Legs       []LegEmissions
+	TotalKgCO2 float64
+}
+
+// BorderCrossing is a leg that leaves one country for another: the flight
+// Start to End, crossing From one ISO 3166-1 alpha-2 country To another.
+type BorderCrossing struct {
+	Start string
+	End   string
+	From  string
+	To    string
+}
+
+// CountryReport lists the countries an itinerary passes through. Countries
+// holds ISO 3166-1 alpha-2 codes in travel order, with consecutive repeats
+// collapsed (a country re-entered later appears again). Crossings lists every
+// international leg and Domestic reports whether the whole itinerary stays in
+// one country.
+type CountryReport struct {
+	Countries []string
+	Crossings []BorderCrossing
+	Domestic  bool
 }
 
 // TestFlights start: BGY; end: AKL.